@@ -2,11 +2,16 @@ package application
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
 	"strconv"
 	"time"
 
+	"github.com/b4ckspace/ledboard-v2/charset"
+	"github.com/b4ckspace/ledboard-v2/emulator"
 	"github.com/b4ckspace/ledboard-v2/ledboard"
 	"github.com/b4ckspace/ledboard-v2/mqttclient"
 	"github.com/b4ckspace/ledboard-v2/screens"
@@ -23,182 +28,455 @@ const (
 	LasercutterMode Mode = "lasercutter"
 )
 
+// Labels of the text files stored on the LED board.
+const (
+	dynamicFile        = ledboard.DefaultFile
+	idleFile           = "I"
+	pizzaFile          = "P"
+	doorBellFile       = "D"
+	donationFile       = "S"
+	laserOperationFile = "L"
+)
+
+// dynamicFiles hold a dynamic screen too long for a single packet, cut into
+// parts shown one after another.
+var dynamicFiles = []string{dynamicFile, "1", "2", "3"}
+
+// ellipsis marks a dynamic screen cut off since it didn't fit into the
+// dynamic files.
+const ellipsis = "..."
+
+// fileAllocations is the memory configuration of the LED board. The dynamic
+// file holds free-form messages, so it gets the most room.
+var fileAllocations = []ledboard.FileAllocation{
+	{Label: dynamicFile, Type: ledboard.FileTypeText, Size: 2048},
+	{Label: dynamicFiles[1], Type: ledboard.FileTypeText, Size: 1536},
+	{Label: dynamicFiles[2], Type: ledboard.FileTypeText, Size: 1536},
+	{Label: dynamicFiles[3], Type: ledboard.FileTypeText, Size: 1536},
+	{Label: idleFile, Type: ledboard.FileTypeText, Size: 256},
+	{Label: pizzaFile, Type: ledboard.FileTypeText, Size: 256},
+	{Label: doorBellFile, Type: ledboard.FileTypeText, Size: 256},
+	{Label: donationFile, Type: ledboard.FileTypeText, Size: 256},
+	{Label: laserOperationFile, Type: ledboard.FileTypeText, Size: 256},
+	{Label: screens.MemberCountString, Type: ledboard.FileTypeString, Size: 8},
+	{Label: screens.StatusString, Type: ledboard.FileTypeString, Size: 4},
+}
+
 // Application holds the dependencies and state for the MQTT message handler.
+// The state is owned by the event loop, see Run.
 type Application struct {
 	ledBoardClient *ledboard.Client
 	mqttClient     *mqttclient.Client
-	pingProbe      *utils.PingProbe
+	pingProbes     []*utils.PingProbe
+	fonts          *ledboard.FontSet
+	pictures       map[string]*ledboard.Picture
 	screens        *screens.Screens
+	timing         *emulator.Board
+	timeline       *timeline
+	clock          *ledboard.ClockSync
+	brightness     *brightness
+	management     ManagementConfig
+	routes         []Route
+	scheduler      *scheduler
 
 	mode     Mode
 	location *time.Location
 
 	memberCount int
 	laserActive bool
+
+	// degraded is set while sending to a sign fails, see runHealthCheck.
+	degraded bool
+
+	events chan event
+	// done is closed once the event loop stopped.
+	done chan struct{}
+	// ctx is the context of Run, requests the event loop starts are
+	// canceled with it.
+	ctx context.Context
 }
 
-// NewApplication creates a new Application.
-func NewApplication(ledBoardClient *ledboard.Client, mqttClient *mqttclient.Client, pingProbe *utils.PingProbe, mode Mode, location *time.Location) *Application {
-	return &Application{
+// NewApplication creates a new Application handling MQTT messages with the
+// routes of its mode, see LoadRoutes. The pictures are uploaded to picture
+// files of their labels, see CheckPictures. The durations of the screens are
+// estimated with the timing of the emulated board.
+func NewApplication(ledBoardClient *ledboard.Client, mqttClient *mqttclient.Client, pingProbes []*utils.PingProbe, fonts *ledboard.FontSet, pictures map[string]*ledboard.Picture, screens *screens.Screens, timing *emulator.Board, mode Mode, location *time.Location, clockSyncInterval time.Duration, brightness BrightnessConfig, management ManagementConfig, routes []Route) *Application {
+	// The topics of the ambient light sensor and the operator commands come
+	// first, no route may take their messages.
+	var active []Route
+	if brightness.LuxTopic != "" {
+		active = append(active, Route{Topic: brightness.LuxTopic, Action: "lux"})
+	}
+	if management.CommandTopic != "" {
+		active = append(active, Route{Topic: management.CommandTopic, Action: "command"})
+	}
+	for _, route := range routes {
+		if len(route.Modes) == 0 || slices.Contains(route.Modes, mode) {
+			active = append(active, route)
+		}
+	}
+
+	app := &Application{
 		ledBoardClient: ledBoardClient,
 		mqttClient:     mqttClient,
-		pingProbe:      pingProbe,
-		screens:        screens.NewScreens(),
+		pingProbes:     pingProbes,
+		fonts:          fonts,
+		pictures:       pictures,
+		screens:        screens,
+		timing:         timing,
+		timeline:       newTimeline(),
+		clock:          ledboard.NewClockSync(ledBoardClient, location, clockSyncInterval),
+		brightness:     newBrightness(brightness, location),
+		management:     management,
+		routes:         active,
 		mode:           mode,
 		location:       location,
+		events:         make(chan event, eventQueueSize),
+		done:           make(chan struct{}),
+		ctx:            context.Background(),
 	}
+	app.scheduler = newScheduler(app)
+	return app
 }
 
-// getIdleScreen returns the appropriate idle screen based on current state.
-func (app *Application) getIdleScreen() string {
+// getIdleFile returns the label of the appropriate idle file based on current state.
+func (app *Application) getIdleFile() string {
 	if app.mode == LasercutterMode && app.laserActive {
-		return app.screens.LaserOperation()
+		return laserOperationFile
 	}
-	return app.screens.Idle(app.memberCount)
+	return idleFile
 }
 
-// Run runs the application based on the specified mode.
-func (app *Application) Run(ctx context.Context) error {
-	// Common MQTT subscriptions
-	if err := app.mqttClient.Subscribe("psa/alarm", app.handleMQTTMessage); err != nil {
-		return fmt.Errorf("failed to subscribe to MQTT topic: %s, %s", "psa/alarm", err)
+// uploadFiles configures the LED board's memory, uploads the custom fonts and
+// stores the static screens. The idle screen goes to flash, so the board shows it after a
+// power cycle even before the daemon notices. The screen shown is shown again.
+func (app *Application) uploadFiles() error {
+	errs := []error{
+		app.ledBoardClient.AllocateMemory(app.allocations()),
+		app.ledBoardClient.UploadFonts(app.fonts),
+		app.writePictures(),
+		app.ledBoardClient.WriteFile(idleFile, ledboard.StorageFlash, app.screens.Idle()),
+		app.writeMemberCount(app.ledBoardClient),
+		app.writeStatus(app.ledBoardClient),
+		app.ledBoardClient.WriteFile(pizzaFile, ledboard.StorageFlash, app.screens.PizzaTimer()),
+		app.ledBoardClient.WriteFile(doorBellFile, ledboard.StorageFlash, app.screens.DoorBell()),
 	}
-	if err := app.mqttClient.Subscribe("psa/pizza", app.handleMQTTMessage); err != nil {
-		return fmt.Errorf("failed to subscribe to MQTT topic: %s, %s", "psa/pizza", err)
+	if app.mode == DefaultMode {
+		errs = append(errs, app.ledBoardClient.WriteFile(donationFile, ledboard.StorageFlash, app.screens.Donation()))
 	}
-	if err := app.mqttClient.Subscribe("psa/message", app.handleMQTTMessage); err != nil {
-		return fmt.Errorf("failed to subscribe to MQTT topic: %s, %s", "psa/message", err)
+	if app.mode == LasercutterMode && app.laserActive {
+		errs = append(errs, app.ledBoardClient.WriteFile(laserOperationFile, ledboard.StorageRAM, app.screens.LaserOperation()))
 	}
-	if err := app.mqttClient.Subscribe("sensor/door/bell", app.handleMQTTMessage); err != nil {
-		return fmt.Errorf("failed to subscribe to MQTT topic: %s, %s", "sensor/door/bell", err)
+	// The screen shown is written again, the allocation erased it.
+	errs = append(errs, app.scheduler.resend(app.ledBoardClient))
+	return errors.Join(errs...)
+}
+
+// allocations returns the memory configuration, fileAllocations and a
+// picture file for each picture.
+func (app *Application) allocations() []ledboard.FileAllocation {
+	allocations := slices.Clone(fileAllocations)
+	for _, label := range slices.Sorted(maps.Keys(app.pictures)) {
+		picture := app.pictures[label]
+		allocations = append(allocations, ledboard.FileAllocation{
+			Label: label, Type: ledboard.FileTypePicture, Width: picture.Width, Height: picture.Height,
+		})
 	}
-	if err := app.mqttClient.Subscribe("sensor/space/member/present", app.handleMQTTMessage); err != nil {
-		return fmt.Errorf("failed to subscribe to MQTT topic: %s, %s", "sensor/space/member/present", err)
+	return allocations
+}
+
+// writePictures stores the pictures in their picture files.
+func (app *Application) writePictures() error {
+	var errs []error
+	for _, label := range slices.Sorted(maps.Keys(app.pictures)) {
+		errs = append(errs, app.ledBoardClient.WritePicture(label, app.pictures[label]))
 	}
+	return errors.Join(errs...)
+}
 
-	// Mode-specific MQTT subscriptions
-	switch app.mode {
-	case DefaultMode:
-		if err := app.mqttClient.Subscribe("psa/donation", app.handleMQTTMessage); err != nil {
-			return fmt.Errorf("failed to subscribe to MQTT topic: %s, %s", "psa/donation", err)
+// CheckPictures checks the labels of the pictures the application uploads:
+// single printable characters not taken by the files it stores itself.
+func CheckPictures(pictures map[string]*ledboard.Picture) error {
+	for label := range pictures {
+		if len(label) != 1 || !charset.Printable(label[0]) {
+			return fmt.Errorf("invalid picture label %q", label)
 		}
-		if err := app.mqttClient.Subscribe("psa/newMember", app.handleMQTTMessage); err != nil {
-			return fmt.Errorf("failed to subscribe to MQTT topic: %s, %s", "psa/newMember", err)
+		if slices.ContainsFunc(fileAllocations, func(a ledboard.FileAllocation) bool { return a.Label == label }) {
+			return fmt.Errorf("picture label %q is taken by a file of the LED board", label)
 		}
-		if err := app.mqttClient.Subscribe("psa/nowPlaying", app.handleMQTTMessage); err != nil {
-			return fmt.Errorf("failed to subscribe to MQTT topic: %s, %s", "psa/nowPlaying", err)
+	}
+	return nil
+}
+
+// pushState brings a sign that came online or recovered up to date: its
+// clock, files and brightness.
+func (app *Application) pushState() {
+	app.clock.Resync()
+	if err := app.uploadFiles(); err != nil {
+		slog.Error("failed to upload files to LED board", "error", err)
+	}
+	app.brightness.reset()
+	app.brightness.update(app.ledBoardClient, time.Now())
+}
+
+// ScreensChanged writes the screens stored on the LED board again, after
+// their templates were reloaded.
+func (app *Application) ScreensChanged() {
+	app.post(screensChangedEvent{})
+}
+
+// writeMemberCount updates the member count the idle screen shows, in place.
+func (app *Application) writeMemberCount(board *ledboard.Client) error {
+	return board.WriteString(screens.MemberCountString, strconv.Itoa(app.memberCount))
+}
+
+// writeStatus updates the status the idle screen shows, in place.
+func (app *Application) writeStatus(board *ledboard.Client) error {
+	return board.WriteString(screens.StatusString, screens.Status(app.degraded))
+}
+
+// show displays the stored files once, then returns to idle.
+func (app *Application) show(board *ledboard.Client, labels ...string) error {
+	return board.SetRunSequence(slices.Concat(labels, []string{app.getIdleFile()})...)
+}
+
+// writeDynamic stores the screen as the dynamic file and returns the files
+// to show. Screens too long for a single packet are spread over the dynamic
+// files, what doesn't fit into them is cut off.
+func (app *Application) writeDynamic(board *ledboard.Client, screen *ledboard.Screen) ([]string, error) {
+	parts, err := board.SplitScreen(screen)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) > len(dynamicFiles) {
+		slog.Warn("screen too long, cutting it off", "files", len(parts), "allocated", len(dynamicFiles))
+		parts = parts[:len(dynamicFiles)]
+		markTruncated(parts[len(parts)-1])
+	}
+	for i, part := range parts {
+		if err := board.WriteFile(dynamicFiles[i], ledboard.StorageRAM, part); err != nil {
+			return nil, err
 		}
-	case LasercutterMode:
-		if err := app.mqttClient.Subscribe("project/laser/operation", app.handleMQTTMessage); err != nil {
-			return fmt.Errorf("failed to subscribe to MQTT topic: %s, %s", "project/laser/operation", err)
+	}
+	return dynamicFiles[:len(parts)], nil
+}
+
+// markTruncated ends the last text of the screen with an ellipsis, keeping
+// its length.
+func markTruncated(screen *ledboard.Screen) {
+	last := screen.Frames[len(screen.Frames)-1]
+	frame := &ledboard.Frame{Segments: slices.Clone(last.Segments)}
+	screen.Frames[len(screen.Frames)-1] = frame
+	for i := len(frame.Segments) - 1; i >= 0; i-- {
+		if text, ok := frame.Segments[i].(ledboard.TextSegment); ok && len(text.Text) >= len(ellipsis) {
+			frame.Segments[i] = ledboard.TextSegment{Text: text.Text[:len(text.Text)-len(ellipsis)] + ellipsis}
+			return
 		}
-		if err := app.mqttClient.Subscribe("project/laser/finished", app.handleMQTTMessage); err != nil {
-			return fmt.Errorf("failed to subscribe to MQTT topic: %s, %s", "project/laser/finished", err)
+	}
+}
+
+// Run runs the application based on the specified mode. MQTT messages, the
+// ping probes and timers post events, handled one after another by the
+// event loop until the context is done.
+func (app *Application) Run(ctx context.Context) error {
+	if err := app.subscribe(app.mqttClient.Subscribe); err != nil {
+		return err
+	}
+	return app.run(ctx)
+}
+
+// subscribe subscribes to the topics of the routes with the subscribe
+// function of the MQTT client.
+func (app *Application) subscribe(subscribe func(filter string, handler mqttlib.MessageHandler) error) error {
+	filters := subscriptions(app.routes)
+	for _, filter := range filters {
+		handler := func(client mqttlib.Client, msg mqttlib.Message) {
+			// A topic matching overlapping filters is handed to each of
+			// their subscriptions, the first one handles it.
+			first := slices.IndexFunc(filters, func(f string) bool { return matchTopic(f, msg.Topic()) })
+			if first >= 0 && filters[first] == filter {
+				app.handleMQTTMessage(client, msg)
+			}
 		}
-		if err := app.mqttClient.Subscribe("project/laser/duration", app.handleMQTTMessage); err != nil {
-			return fmt.Errorf("failed to subscribe to MQTT topic: %s, %s", "project/laser/duration", err)
+		if err := subscribe(filter, handler); err != nil {
+			return fmt.Errorf("failed to subscribe to MQTT topic: %s, %s", filter, err)
 		}
 	}
+	return nil
+}
 
-	err := app.pingProbe.Run(ctx, func() {
-		slog.Info("ledboard is alive, setting date and sending idle screen")
-		app.ledBoardClient.SetDate(time.Now().In(app.location))
-		app.ledBoardClient.SendScreen(app.getIdleScreen())
-	})
-	if err != nil {
-		return fmt.Errorf("issues while pinging: %s", err)
+// run starts the timers and ping probes and runs the event loop until the
+// context is done.
+func (app *Application) run(ctx context.Context) error {
+	app.ctx = ctx
+	go func() {
+		_ = app.clock.Run(ctx)
+	}()
+	go app.runBrightness(ctx)
+	go app.runState(ctx)
+	go app.runHealthCheck(ctx)
+
+	errs := make(chan error, len(app.pingProbes))
+	for _, pingProbe := range app.pingProbes {
+		go func() {
+			errs <- pingProbe.Run(ctx, func() {
+				slog.Info("ledboard is alive, setting date and uploading files")
+				app.post(pushStateEvent{})
+			})
+		}()
 	}
 
-	<-ctx.Done()
+	if err := app.loop(ctx, errs); err != nil {
+		return fmt.Errorf("issues while pinging: %s", err)
+	}
 	slog.Info("Application context cancelled. Disconnecting MQTT client.")
 	app.mqttClient.Disconnect()
 	return nil
 }
 
-// handleMQTTMessage processes incoming MQTT messages.
-func (app *Application) handleMQTTMessage(client mqttlib.Client, msg mqttlib.Message) {
-	message := string(msg.Payload())
-	slog.Info("Received MQTT message", "topic", msg.Topic(), "value", message)
-
-	switch msg.Topic() {
-	case "sensor/space/member/present":
-		count, err := strconv.Atoi(message)
-		if err != nil {
-			slog.Error("Error converting member count", "error", err)
+// runBrightness follows the brightness schedule until the context is done.
+func (app *Application) runBrightness(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
 			return
+		case now := <-ticker.C:
+			app.post(brightnessEvent{now: now})
 		}
-		app.memberCount = count
-
-		if !app.laserActive {
-			app.ledBoardClient.SendScreen(app.getIdleScreen())
-		}
-
-	case "psa/pizza":
-		app.ledBoardClient.SendScreens([]string{app.screens.PizzaTimer(), app.getIdleScreen()})
-
-	case "psa/donation":
-		app.ledBoardClient.SendScreens([]string{app.screens.Donation(), app.getIdleScreen()})
-
-	case "psa/alarm":
-		app.ledBoardClient.SendScreens([]string{app.screens.Alarm(message), app.getIdleScreen()})
+	}
+}
 
-	case "psa/newMember":
-		app.ledBoardClient.SendScreens([]string{app.screens.NewMemberRegistration(message), app.getIdleScreen()})
+// handleMQTTMessage hands incoming MQTT messages to the event loop.
+func (app *Application) handleMQTTMessage(client mqttlib.Client, msg mqttlib.Message) {
+	app.post(messageEvent{topic: msg.Topic(), payload: string(msg.Payload())})
+}
 
-	case "sensor/door/bell":
-		if message == "pressed" {
-			app.ledBoardClient.SendScreens([]string{app.screens.DoorBell(), app.getIdleScreen()})
+// handle updates the board for a single MQTT message, following the first
+// route matching it.
+func (app *Application) handle(board *ledboard.Client, topic, message string) error {
+	for i := range app.routes {
+		if route := &app.routes[i]; route.matches(topic, message) {
+			return app.route(board, route, topic, message)
 		}
+	}
+	slog.Debug("no route for MQTT message", "topic", topic)
+	return nil
+}
 
-	case "psa/message":
-		if message != "" {
-			app.ledBoardClient.SendScreens([]string{app.screens.PublicServiceAnnouncement(message), app.getIdleScreen()})
-		}
+// route shows the route's screen and runs its action for the message.
+func (app *Application) route(board *ledboard.Client, route *Route, topic, message string) error {
+	values, err := route.values(topic, message)
+	if err != nil {
+		return err
+	}
+	if route.Screen != "" {
+		err = app.showRoute(board, route, values)
+	}
+	if route.Action != "" {
+		err = errors.Join(err, actions[route.Action](app, board, values))
+	}
+	return err
+}
 
-	case "psa/nowPlaying":
-		if message != "" {
-			app.ledBoardClient.SendScreens([]string{app.screens.NowPlaying(message), app.getIdleScreen()})
-		}
+// showRoute hands the route's screen to the scheduler. Screens stored on the
+// sign are shown from their file.
+func (app *Application) showRoute(board *ledboard.Client, route *Route, values routeValues) error {
+	screen, err := app.screens.Render(route.Screen, values.message, values.duration)
+	if err != nil {
+		return err
+	}
+	duration := app.timing.Duration(screen, time.Now().In(app.location))
+	item := &scheduled{
+		board:      board,
+		name:       route.Screen,
+		screen:     screen,
+		duration:   duration,
+		priority:   route.Priority,
+		minDisplay: route.minDisplay(duration),
+		expires:    time.Now().Add(route.expiry()),
+		idle:       route.returnsToIdle(),
+	}
+	if label, ok := app.storedFile(route.Screen); ok {
+		// The stored screen is rendered the same, for its duration only.
+		item.screen, item.labels = nil, []string{label}
+	}
+	return app.scheduler.submit(item)
+}
 
-	case "project/laser/operation":
-		if message == "active" {
-			app.laserActive = true
+// storedFile returns the file a screen is stored in by uploadFiles.
+func (app *Application) storedFile(screen string) (string, bool) {
+	switch screen {
+	case "idle":
+		return idleFile, true
+	case "pizza":
+		return pizzaFile, true
+	case "doorbell":
+		return doorBellFile, true
+	case "donation":
+		return donationFile, app.mode == DefaultMode
+	}
+	return "", false
+}
 
-			// Use the internal datetime to produce a counting screen!
-			nullDate := time.Date(2000, time.February, 0, 0, 0, 2, 0, time.UTC)
-			app.ledBoardClient.SetDate(nullDate)
+// setMemberCount updates the member count shown and the brightness.
+func (app *Application) setMemberCount(board *ledboard.Client, values routeValues) error {
+	count, err := strconv.Atoi(values.message)
+	if err != nil {
+		return fmt.Errorf("error converting member count: %w", err)
+	}
+	app.memberCount = count
+	app.brightness.setMembers(count)
+	app.brightness.update(board, time.Now())
 
-			app.ledBoardClient.SendScreen(app.screens.LaserOperation())
-		} else {
-			app.laserActive = false
-		}
+	return app.writeMemberCount(board)
+}
 
-	case "project/laser/duration":
-		duration, err := strconv.Atoi(message)
-		if err != nil {
-			slog.Error("Error converting duration", "error", err)
-			return
-		}
+// doorBellBeep sounds the door bell beep, if there is one.
+func (app *Application) doorBellBeep(board *ledboard.Client, values routeValues) error {
+	if beep := app.management.DoorBellBeep; beep != nil {
+		return board.Beep(*beep)
+	}
+	return nil
+}
 
-		minutes := (duration % 3600) / 60
-		seconds := duration % 60
+// startLaser shows the time the laser is running for until it finishes.
+func (app *Application) startLaser(board *ledboard.Client, values routeValues) error {
+	app.laserActive = true
+
+	// Use the internal datetime to produce a counting screen!
+	app.clock.Pause()
+	nullDate := time.Date(2000, time.February, 0, 0, 0, 0, 0, time.UTC)
+	return errors.Join(
+		board.SetDate(nullDate),
+		board.WriteFile(laserOperationFile, ledboard.StorageRAM, app.screens.LaserOperation()),
+		app.scheduler.showIdle(board),
+	)
+}
 
-		if minutes%2 == 0 && seconds == 57 {
-			correction := time.Date(2000, time.February, 0, 0, minutes+1, 0, 0, time.UTC)
-			app.ledBoardClient.SetDate(correction)
-		}
+func (app *Application) stopLaser(board *ledboard.Client, values routeValues) error {
+	app.laserActive = false
+	return nil
+}
 
-	case "project/laser/finished":
-		if message != "" {
-			duration, err := strconv.Atoi(message)
-			if err != nil {
-				slog.Error("Error converting duration", "error", err)
-				return
-			}
-			app.ledBoardClient.SendScreens([]string{app.screens.LaserFinished(duration), app.getIdleScreen()})
+// correctLaserDuration keeps the time shown in line with the duration the
+// laser reports.
+func (app *Application) correctLaserDuration(board *ledboard.Client, values routeValues) error {
+	minutes := (values.duration % 3600) / 60
+	seconds := values.duration % 60
 
-			// Reset datetime to something useful
-			app.ledBoardClient.SetDate(time.Now().In(app.location))
-		}
+	if minutes%2 == 0 && seconds == 57 {
+		correction := time.Date(2000, time.February, 0, 0, minutes+1, 0, 0, time.UTC)
+		return board.SetDate(correction)
 	}
+	return nil
+}
+
+// finishLaser hands the clock back to the clock sync, after the laser
+// finished screen was shown.
+func (app *Application) finishLaser(board *ledboard.Client, values routeValues) error {
+	// Reset datetime to something useful
+	app.clock.Resume()
+	return nil
 }
@@ -0,0 +1,200 @@
+package application
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/emulator"
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+	"github.com/b4ckspace/ledboard-v2/mqttclient"
+	"github.com/b4ckspace/ledboard-v2/screens"
+	"github.com/b4ckspace/ledboard-v2/simulator"
+
+	mqttlib "github.com/eclipse/paho.mqtt.golang"
+)
+
+// message is an MQTT message as the client hands it to a subscription.
+type message struct {
+	topic   string
+	payload []byte
+}
+
+func (m message) Duplicate() bool   { return false }
+func (m message) Qos() byte         { return 0 }
+func (m message) Retained() bool    { return false }
+func (m message) Topic() string     { return m.topic }
+func (m message) MessageID() uint16 { return 0 }
+func (m message) Payload() []byte   { return m.payload }
+func (m message) Ack()              {}
+
+// testSign is a simulated sign on a local UDP port, keeping the packets it
+// received.
+type testSign struct {
+	ledboard.Sign
+
+	mu      sync.Mutex
+	packets []*ledboard.Packet
+}
+
+// startSign serves a simulated sign until the test ends.
+func startSign(t *testing.T) *testSign {
+	t.Helper()
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	s := &testSign{}
+	if s.Sign, err = ledboard.ParseSign("udp://" + conn.LocalAddr().String()); err != nil {
+		t.Fatal(err)
+	}
+	sim := simulator.NewSign(screens.DefaultBoard.Width, screens.DefaultBoard.Height)
+	go func() {
+		buf := make([]byte, 65536)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			datagram := string(buf[:n])
+			if packet, err := ledboard.Decode(datagram); err == nil {
+				s.mu.Lock()
+				s.packets = append(s.packets, packet)
+				s.mu.Unlock()
+			}
+			if reply, _ := sim.Handle(datagram); reply != "" {
+				_, _ = conn.WriteTo([]byte(reply), from)
+			}
+		}
+	}()
+	return s
+}
+
+// waitFor waits until the packets received meet done and returns them.
+func (s *testSign) waitFor(t *testing.T, done func(packets []*ledboard.Packet) bool) []*ledboard.Packet {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+		s.mu.Lock()
+		packets := slices.Clone(s.packets)
+		s.mu.Unlock()
+		if done(packets) {
+			return packets
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("sign did not receive the packets expected, got:\n%s", packets)
+		}
+	}
+}
+
+// newTestApp returns an application with the default routes, sending to a
+// simulated sign. The event loop isn't running, tests dispatch the events.
+func newTestApp(t *testing.T, mode Mode) (*Application, *testSign) {
+	t.Helper()
+	sign := startSign(t)
+	client, err := ledboard.NewClient([]ledboard.Sign{sign.Sign}, nil, ledboard.QueueOptions{Gap: time.Millisecond, Size: 32})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+
+	s := screens.NewScreens(screens.DefaultBoard, nil)
+	routes, err := LoadRoutes("", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	brightness := BrightnessConfig{LuxTopic: "sensor/space/lux", LuxFull: 300, Min: 10, Empty: 20}
+	management := ManagementConfig{CommandTopic: "ledboard/command"}
+	timing := emulator.NewBoard(screens.DefaultBoard.Width, screens.DefaultBoard.Height)
+	app := NewApplication(client, mqttclient.NewClient(), nil, ledboard.NewFontSet(), nil, s, timing, mode, time.UTC,
+		time.Hour, brightness, management, routes)
+	t.Cleanup(func() {
+		if app.scheduler.timer != nil {
+			app.scheduler.timer.Stop()
+		}
+	})
+	return app, sign
+}
+
+// quiet drops the log output of the test.
+func quiet(t *testing.T) {
+	logger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	t.Cleanup(func() { slog.SetDefault(logger) })
+}
+
+// TestConcurrentMessages publishes to every routed topic from many goroutines
+// at once, the race detector checks that the state is only changed by the
+// event loop.
+func TestConcurrentMessages(t *testing.T) {
+	quiet(t)
+	payloads := []string{
+		"", "0", "3", "42", "pressed", "active", "inactive", "Grüße {aus} dem Space",
+		strings.Repeat("A long announcement ", 100), "beep", "config", "test on", "test off", "speaker on",
+	}
+	const publishers, rounds = 16, 4
+
+	for _, mode := range []Mode{DefaultMode, LasercutterMode} {
+		t.Run(string(mode), func(t *testing.T) {
+			app, _ := newTestApp(t, mode)
+
+			handlers := make(map[string]mqttlib.MessageHandler)
+			err := app.subscribe(func(filter string, handler mqttlib.MessageHandler) error {
+				handlers[filter] = handler
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var topics []string
+			for _, route := range app.routes {
+				if !slices.Contains(topics, route.Topic) {
+					topics = append(topics, route.Topic)
+				}
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			done := make(chan error, 1)
+			go func() { done <- app.run(ctx) }()
+
+			var wg sync.WaitGroup
+			for i := range publishers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for round := range rounds {
+						for j, topic := range topics {
+							msg := message{topic: topic, payload: []byte(payloads[(i+j+round)%len(payloads)])}
+							// The broker hands the message to every matching
+							// subscription.
+							for filter, handler := range handlers {
+								if matchTopic(filter, topic) {
+									handler(nil, msg)
+								}
+							}
+						}
+						_ = app.State()
+					}
+					app.ScreensChanged()
+				}()
+			}
+			wg.Wait()
+
+			cancel()
+			if err := <-done; err != nil {
+				t.Fatal(err)
+			}
+			if state := app.State(); state.Screen == "" {
+				t.Errorf("no screen shown: %+v", state)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package application
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+)
+
+const (
+	// luxSmoothing is the weight of a new lux reading in the running average,
+	// so a passing shadow doesn't dim the sign.
+	luxSmoothing = 0.2
+
+	// brightnessHysteresis is the least change in percent sent to the sign,
+	// smaller ones would make it flicker between two levels.
+	brightnessHysteresis = 5
+)
+
+// BrightnessStep sets the brightness from a time of day on.
+type BrightnessStep struct {
+	// Minute of the day the step starts at.
+	Minute  int
+	Percent int
+}
+
+// BrightnessConfig controls how bright the sign is.
+type BrightnessConfig struct {
+	// Schedule holds the brightness over the day, full brightness if empty.
+	Schedule []BrightnessStep
+	// Empty is the brightness while nobody is present.
+	Empty int
+	// LuxTopic is the MQTT topic of an ambient light sensor, optional.
+	LuxTopic string
+	// LuxFull is the ambient light the sign needs full brightness in, it is
+	// dimmed linearly below, down to Min.
+	LuxFull float64
+	// Min is the least brightness ambient light dims the sign to.
+	Min int
+}
+
+// ParseBrightnessSchedule parses steps like "07:00=100" into a schedule.
+func ParseBrightnessSchedule(steps []string) ([]BrightnessStep, error) {
+	var schedule []BrightnessStep
+	for _, step := range steps {
+		at, value, ok := strings.Cut(step, "=")
+		if !ok {
+			return nil, fmt.Errorf("brightness step %q: expected HH:MM=percent", step)
+		}
+		t, err := time.Parse("15:04", at)
+		if err != nil {
+			return nil, fmt.Errorf("brightness step %q: invalid time of day", step)
+		}
+		percent, err := strconv.Atoi(value)
+		if err != nil || percent < 0 || percent > ledboard.MaxBrightness {
+			return nil, fmt.Errorf("brightness step %q: invalid percentage", step)
+		}
+		schedule = append(schedule, BrightnessStep{Minute: t.Hour()*60 + t.Minute(), Percent: percent})
+	}
+	slices.SortFunc(schedule, func(a, b BrightnessStep) int { return a.Minute - b.Minute })
+	return schedule, nil
+}
+
+// brightness decides how bright the sign should be from the schedule, the
+// people present and the ambient light. It is used by the event loop only.
+type brightness struct {
+	config   BrightnessConfig
+	location *time.Location
+
+	// members is the number of people present, -1 until it is known.
+	members int
+	// lux is the smoothed ambient light, -1 until it is known.
+	lux float64
+	// current is what was sent to the sign, -1 if it has to be sent again.
+	current int
+}
+
+func newBrightness(config BrightnessConfig, location *time.Location) *brightness {
+	return &brightness{config: config, location: location, members: -1, lux: -1, current: -1}
+}
+
+func (b *brightness) setMembers(members int) {
+	b.members = members
+}
+
+func (b *brightness) addLux(lux float64) {
+	if b.lux < 0 {
+		b.lux = lux
+		return
+	}
+	b.lux = luxSmoothing*lux + (1-luxSmoothing)*b.lux
+}
+
+// reset makes the next update send the brightness, e.g. after the sign
+// came online.
+func (b *brightness) reset() {
+	b.current = -1
+}
+
+// target returns the brightness for the time, the dimmest of all inputs.
+func (b *brightness) target(now time.Time) int {
+	percent := ledboard.MaxBrightness
+	if schedule := b.config.Schedule; len(schedule) > 0 {
+		local := now.In(b.location)
+		minute := local.Hour()*60 + local.Minute()
+		// Before the first step of the day the last step of the day before holds.
+		percent = schedule[len(schedule)-1].Percent
+		for _, step := range schedule {
+			if step.Minute <= minute {
+				percent = step.Percent
+			}
+		}
+	}
+	if b.members == 0 {
+		percent = min(percent, b.config.Empty)
+	}
+	if b.config.LuxFull > 0 && b.lux >= 0 {
+		ratio := min(b.lux/b.config.LuxFull, 1)
+		percent = min(percent, b.config.Min+int(ratio*float64(ledboard.MaxBrightness-b.config.Min)))
+	}
+	return percent
+}
+
+// addLux takes a reading of the ambient light sensor. Negative and infinite
+// readings are rejected, as is NaN, which would never leave the average.
+func (app *Application) addLux(board *ledboard.Client, values routeValues) error {
+	lux, err := strconv.ParseFloat(values.message, 64)
+	if err != nil {
+		return fmt.Errorf("error converting ambient light: %w", err)
+	}
+	if math.IsNaN(lux) || math.IsInf(lux, 0) || lux < 0 {
+		return fmt.Errorf("invalid ambient light %v", lux)
+	}
+	app.brightness.addLux(lux)
+	app.brightness.update(board, time.Now())
+	return nil
+}
+
+// update sends the brightness to the sign if it changed noticeably.
+func (b *brightness) update(board *ledboard.Client, now time.Time) {
+	percent := b.target(now)
+	if b.current >= 0 && abs(percent-b.current) < brightnessHysteresis &&
+		percent != ledboard.MaxBrightness && percent != b.config.Min {
+		return
+	}
+	if percent == b.current {
+		return
+	}
+	slog.Debug("brightness changed", "percent", percent, "members", b.members, "lux", b.lux)
+	if err := board.SetBrightness(percent); err != nil {
+		slog.Error("failed to set brightness", "error", err)
+		b.current = -1
+		return
+	}
+	b.current = percent
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
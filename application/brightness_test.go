@@ -0,0 +1,121 @@
+package application
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+)
+
+func TestBrightnessSmoothing(t *testing.T) {
+	b := newBrightness(BrightnessConfig{}, time.UTC)
+	// The first reading is taken as is, later ones count a fifth.
+	for _, step := range []struct{ lux, want float64 }{
+		{100, 100},
+		{200, 120},
+		{200, 136},
+		{0, 108.8},
+		{108.8, 108.8},
+	} {
+		b.addLux(step.lux)
+		if math.Abs(b.lux-step.want) > 1e-9 {
+			t.Errorf("after %v lux the average is %v, want %v", step.lux, b.lux, step.want)
+		}
+	}
+}
+
+func TestBrightnessTarget(t *testing.T) {
+	config := BrightnessConfig{
+		Schedule: []BrightnessStep{{Minute: 7 * 60, Percent: 100}, {Minute: 22 * 60, Percent: 30}},
+		Empty:    20,
+		LuxFull:  300,
+		Min:      10,
+	}
+	// The schedule is in local time, an hour ahead of UTC.
+	local := time.FixedZone("CET", 3600)
+	at := func(hour, min int) time.Time { return time.Date(2026, time.January, 15, hour, min, 0, 0, time.UTC) }
+	tests := []struct {
+		name    string
+		config  BrightnessConfig
+		now     time.Time
+		members int
+		lux     float64
+		want    int
+	}{
+		{name: "no schedule", config: BrightnessConfig{}, now: at(3, 0), members: -1, lux: -1, want: 100},
+		{name: "before the first step", config: config, now: at(5, 59), members: -1, lux: -1, want: 30},
+		{name: "first step", config: config, now: at(6, 0), members: -1, lux: -1, want: 100},
+		{name: "last step", config: config, now: at(21, 0), members: -1, lux: -1, want: 30},
+		{name: "present", config: config, now: at(12, 0), members: 3, lux: -1, want: 100},
+		{name: "empty", config: config, now: at(12, 0), members: 0, lux: -1, want: 20},
+		{name: "empty at night", config: BrightnessConfig{Schedule: config.Schedule, Empty: 50}, now: at(23, 0), members: 0, lux: -1, want: 30},
+		{name: "dark", config: config, now: at(12, 0), members: 3, lux: 0, want: 10},
+		{name: "dim", config: config, now: at(12, 0), members: 3, lux: 150, want: 55},
+		{name: "daylight", config: config, now: at(12, 0), members: 3, lux: 3000, want: 100},
+		{name: "dim at night", config: config, now: at(23, 0), members: 3, lux: 150, want: 30},
+		{name: "dim and empty", config: config, now: at(12, 0), members: 0, lux: 150, want: 20},
+		{name: "no sensor", config: BrightnessConfig{Schedule: config.Schedule}, now: at(12, 0), members: 3, lux: 0, want: 100},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := newBrightness(test.config, local)
+			b.members, b.lux = test.members, test.lux
+			if got := b.target(test.now); got != test.want {
+				t.Errorf("target = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBrightnessUpdate(t *testing.T) {
+	quiet(t)
+	sign := startSign(t)
+	client, err := ledboard.NewClient([]ledboard.Sign{sign.Sign}, nil, ledboard.QueueOptions{Gap: time.Millisecond, Size: 32})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// A step an hour drives the target. Changes below the hysteresis are
+	// skipped, unless they reach full brightness or Min.
+	steps := []struct{ percent, sent int }{
+		{100, 100}, {94, 94}, {92, 94}, {97, 94}, {13, 13}, {10, 10}, {100, 100}, {97, 100}, {100, 100},
+	}
+	var schedule []BrightnessStep
+	for hour, step := range steps {
+		schedule = append(schedule, BrightnessStep{Minute: hour * 60, Percent: step.percent})
+	}
+	b := newBrightness(BrightnessConfig{Schedule: schedule, Min: 10}, time.UTC)
+	for hour, step := range steps {
+		b.update(client, time.Date(2026, time.January, 15, hour, 30, 0, 0, time.UTC))
+		if b.current != step.sent {
+			t.Errorf("at %d%% the sign is set to %d%%, want %d%%", step.percent, b.current, step.sent)
+		}
+	}
+
+	// brightnesses returns what the sign was set to.
+	brightnesses := func(packets []*ledboard.Packet) []int {
+		var percents []int
+		for _, packet := range packets {
+			if packet.Function == ledboard.FunctionBrightness {
+				percents = append(percents, packet.Brightness)
+			}
+		}
+		return percents
+	}
+	// Wait for the queue to drain, the updates replace each other in it.
+	sent := len(brightnesses(sign.waitFor(t, func(packets []*ledboard.Packet) bool {
+		queue := client.Stats()[0].Queue
+		return queue.Pending == 0 && queue.Sent == uint64(len(packets))
+	})))
+
+	// After a reset the brightness is sent again, even if unchanged.
+	b.reset()
+	b.update(client, time.Date(2026, time.January, 15, 8, 30, 0, 0, time.UTC))
+	sign.waitFor(t, func(packets []*ledboard.Packet) bool {
+		return slices.Equal(brightnesses(packets)[sent:], []int{100})
+	})
+}
@@ -0,0 +1,124 @@
+package application
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+)
+
+// eventQueueSize is the number of events waiting for the event loop, the
+// inputs block beyond.
+const eventQueueSize = 64
+
+// event is an input of the event loop. The state of the Application is only
+// changed and the LED board only written from the loop, see Run.
+type event interface{}
+
+// messageEvent is a message of a subscribed MQTT topic.
+type messageEvent struct {
+	topic   string
+	payload string
+}
+
+// pushStateEvent brings a sign up to date that came online, recovered or
+// restarted.
+type pushStateEvent struct{}
+
+// screensChangedEvent is sent after the screen templates were reloaded.
+type screensChangedEvent struct{}
+
+// brightnessEvent is the tick of the brightness schedule.
+type brightnessEvent struct {
+	now time.Time
+}
+
+// healthEvent reports the delivery stats of the signs, see runHealthCheck.
+type healthEvent struct {
+	degraded  bool
+	recovered bool
+}
+
+// elapsedEvent is sent once a screen had its minimum display time.
+type elapsedEvent struct {
+	item *scheduled
+}
+
+// configEvent carries the settings read by the config command.
+type configEvent struct {
+	results []ledboard.Result
+	err     error
+}
+
+// post hands the event to the event loop, waiting while the queue is full.
+// Events posted after the loop stopped are dropped.
+func (app *Application) post(ev event) {
+	select {
+	case app.events <- ev:
+	case <-app.done:
+	}
+}
+
+// loop handles the events one after another until the context is done or
+// a ping probe fails.
+func (app *Application) loop(ctx context.Context, probeErrs <-chan error) error {
+	defer close(app.done)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-probeErrs:
+			if err != nil {
+				return err
+			}
+		case ev := <-app.events:
+			app.dispatch(ev)
+		}
+	}
+}
+
+func (app *Application) dispatch(ev event) {
+	switch ev := ev.(type) {
+	case messageEvent:
+		slog.Info("Received MQTT message", "topic", ev.topic, "value", ev.payload)
+
+		// Everything sent to the board from here on is recorded with the message.
+		board := app.ledBoardClient.WithTrigger(ledboard.Trigger{Topic: ev.topic, Payload: ev.payload})
+
+		if err := app.handle(board, ev.topic, ev.payload); err != nil {
+			slog.Error("failed to handle MQTT message", "topic", ev.topic, "error", err)
+		}
+
+	case pushStateEvent:
+		app.pushState()
+
+	case screensChangedEvent:
+		if err := app.uploadFiles(); err != nil {
+			slog.Error("failed to upload files to LED board", "error", err)
+		}
+
+	case brightnessEvent:
+		app.brightness.update(app.ledBoardClient, ev.now)
+
+	case healthEvent:
+		changed := app.degraded != ev.degraded
+		app.degraded = ev.degraded
+		if ev.recovered {
+			app.pushState()
+		} else if changed {
+			if err := app.writeStatus(app.ledBoardClient); err != nil {
+				slog.Error("failed to update status", "error", err)
+			}
+		}
+
+	case elapsedEvent:
+		app.scheduler.elapsed(ev.item)
+
+	case configEvent:
+		logConfig(ev.results, ev.err)
+
+	default:
+		slog.Error("unknown event", "event", ev)
+	}
+}
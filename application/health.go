@@ -0,0 +1,48 @@
+package application
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// healthCheckInterval is how often the delivery stats of the signs are checked.
+const healthCheckInterval = 10 * time.Second
+
+// runHealthCheck watches the delivery stats of the signs until the context is
+// done and posts them to the event loop. While sending to a sign fails, the
+// idle screen shows degraded status. Once it recovers, the sign may have
+// missed anything, so the whole state is pushed again.
+func (app *Application) runHealthCheck(ctx context.Context) {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	failing := make(map[string]bool)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		recovered := false
+		for _, stats := range app.ledBoardClient.Stats() {
+			name := stats.Sign.Name
+			switch {
+			case stats.Failing() && !failing[name]:
+				slog.Warn("sending to LED board fails", "sign", name, "error", stats.LastError,
+					"errors", stats.Errors, "last_success", stats.LastSuccess)
+			case !stats.Failing() && failing[name]:
+				slog.Info("LED board recovered", "sign", name, "packets", stats.Packets)
+				recovered = true
+			}
+			failing[name] = stats.Failing()
+		}
+
+		degraded := false
+		for _, f := range failing {
+			degraded = degraded || f
+		}
+		app.post(healthEvent{degraded: degraded, recovered: recovered})
+	}
+}
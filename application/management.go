@@ -0,0 +1,129 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+)
+
+// resetDelay is how long a sign takes to restart after a soft reset or to
+// clear its memory, packets sent meanwhile may be lost.
+const resetDelay = 5 * time.Second
+
+// defaultBeep is sounded by the beep command without a tone.
+var defaultBeep = ledboard.Beep{Frequency: 0x80, Duration: 500 * time.Millisecond}
+
+// ManagementConfig controls the management of the sign over MQTT.
+type ManagementConfig struct {
+	// CommandTopic is the MQTT topic operators send commands like "reset"
+	// to, disabled if empty.
+	CommandTopic string
+	// StateTopic is the MQTT topic what the sign shows is published to as
+	// retained JSON, see State. Disabled if empty.
+	StateTopic string
+	// DoorBellBeep is sounded when the door bell rings, nil to stay quiet.
+	DoorBellBeep *ledboard.Beep
+}
+
+// runCommand runs the operator command of a message.
+func (app *Application) runCommand(board *ledboard.Client, values routeValues) error {
+	return app.handleCommand(board, values.message)
+}
+
+// handleCommand runs an operator command:
+//
+//	reset                      restart the sign and upload everything again
+//	clear                      erase the sign's memory and upload everything again
+//	beep [frequency,duration,repeats]
+//	test on|off                show the built-in test pattern
+//	speaker on|off             enable or disable the speaker
+//	config                     log the sign's settings
+func (app *Application) handleCommand(board *ledboard.Client, command string) error {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return errors.New("empty command")
+	}
+	args := fields[1:]
+
+	switch fields[0] {
+	case "reset":
+		if err := board.SoftReset(); err != nil {
+			return err
+		}
+		time.AfterFunc(resetDelay, func() { app.post(pushStateEvent{}) })
+		return nil
+
+	case "clear":
+		if err := board.ClearMemory(); err != nil {
+			return err
+		}
+		time.AfterFunc(resetDelay, func() { app.post(pushStateEvent{}) })
+		return nil
+
+	case "beep":
+		beep := defaultBeep
+		if len(args) > 0 {
+			var err error
+			if beep, err = ledboard.ParseBeep(args[0]); err != nil {
+				return err
+			}
+		}
+		return board.Beep(beep)
+
+	case "test":
+		on, err := onOff(args)
+		if err != nil {
+			return fmt.Errorf("test: %w", err)
+		}
+		return board.ShowTestPattern(on)
+
+	case "speaker":
+		on, err := onOff(args)
+		if err != nil {
+			return fmt.Errorf("speaker: %w", err)
+		}
+		// Times of day are always shown in 24 hour format.
+		return board.SetConfig(ledboard.SignConfig{Speaker: on, Clock24: true})
+
+	case "config":
+		// Waiting for the answers would hold up the event loop.
+		go func(ctx context.Context) {
+			results, err := board.ReadConfig(ctx, ledboard.DefaultPolicy)
+			app.post(configEvent{results: results, err: err})
+		}(app.ctx)
+		return nil
+	}
+	return fmt.Errorf("unknown command %q", fields[0])
+}
+
+// logConfig logs the settings of the signs read by the config command.
+func logConfig(results []ledboard.Result, err error) {
+	for _, result := range results {
+		if result.Status != ledboard.StatusAcked || result.Packet == nil {
+			slog.Warn("LED board configuration not available", "sign", result.Sign.Name, "status", result.Status)
+			continue
+		}
+		config := result.Packet.Config
+		slog.Info("LED board configuration", "sign", result.Sign.Name, "speaker", config.Speaker, "clock24", config.Clock24)
+	}
+	if err != nil {
+		slog.Error("failed to read LED board configuration", "error", err)
+	}
+}
+
+func onOff(args []string) (bool, error) {
+	if len(args) == 1 {
+		switch args[0] {
+		case "on":
+			return true, nil
+		case "off":
+			return false, nil
+		}
+	}
+	return false, errors.New("expected on or off")
+}
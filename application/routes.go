@@ -0,0 +1,403 @@
+package application
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+	"github.com/b4ckspace/ledboard-v2/screens"
+)
+
+// defaultRoutes is the routing table used unless a routing file is loaded.
+//
+//go:embed routes.json
+var defaultRoutes []byte
+
+// Route maps the MQTT messages of a topic to what the sign shows. Messages
+// follow the first route of the table matching them.
+type Route struct {
+	// Topic is the topic filter, "+" matching a single level and "#" any
+	// number of levels at the end.
+	Topic string `json:"topic"`
+	// Modes are the modes the route is used in, all if empty.
+	Modes []Mode `json:"modes,omitempty"`
+	// When is the condition the message has to meet, any message if nil.
+	When *Condition `json:"when,omitempty"`
+	// Screen is shown once. Screens stored on the sign, like pizza, are
+	// shown from their file, others are rendered with the params.
+	Screen string `json:"screen,omitempty"`
+	// Params take the "message" and "duration" the screen and the action
+	// get from the message, see parseValue. The message defaults to the
+	// payload.
+	Params map[string]string `json:"params,omitempty"`
+	// Idle returns to the idle screen after the screen, the default. Else
+	// the screen is repeated until the next one.
+	Idle *bool `json:"idle,omitempty"`
+	// Action is a builtin action run after the screen, see actions.
+	Action string `json:"action,omitempty"`
+
+	// Priority of the screen, a higher one preempts the screen shown.
+	Priority int `json:"priority,omitempty"`
+	// MinSeconds is how long the screen is shown at least before one of the
+	// same or a lower priority follows. If zero, until it played once, up to
+	// maxMinDisplay.
+	MinSeconds int `json:"minSeconds,omitempty"`
+	// ExpireSeconds is how long the screen waits to be shown before it is
+	// dropped, defaultExpiry if zero.
+	ExpireSeconds int `json:"expireSeconds,omitempty"`
+
+	params map[string]value
+}
+
+// Condition is met by a value of the message equal to Equals and matching
+// Regex, those set. A condition without either is met if the value exists.
+type Condition struct {
+	// Value is the value compared, the payload if empty. See parseValue.
+	Value  string  `json:"value,omitempty"`
+	Equals *string `json:"equals,omitempty"`
+	Regex  string  `json:"regex,omitempty"`
+
+	value value
+	regex *regexp.Regexp
+}
+
+// routeValues are what a route takes from a message.
+type routeValues struct {
+	message  string
+	duration int
+}
+
+// actions are the builtin actions of routes.
+var actions = map[string]func(app *Application, board *ledboard.Client, values routeValues) error{
+	"members":        (*Application).setMemberCount,
+	"lux":            (*Application).addLux,
+	"command":        (*Application).runCommand,
+	"doorbell-beep":  (*Application).doorBellBeep,
+	"laser-start":    (*Application).startLaser,
+	"laser-stop":     (*Application).stopLaser,
+	"laser-duration": (*Application).correctLaserDuration,
+	"laser-finished": (*Application).finishLaser,
+}
+
+// LoadRoutes reads the routing table from the file at path, a JSON array
+// of routes, or returns the default table if path is empty. Screens are
+// looked up in the current screen templates.
+func LoadRoutes(path string, screens *screens.Screens) ([]Route, error) {
+	data := defaultRoutes
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	var routes []Route
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&routes); err != nil {
+		return nil, fmt.Errorf("invalid routes %s: %w", path, err)
+	}
+	for i := range routes {
+		if err := routes[i].compile(screens); err != nil {
+			return nil, fmt.Errorf("route %d (%s): %w", i+1, routes[i].Topic, err)
+		}
+	}
+	return routes, nil
+}
+
+func (r *Route) compile(screens *screens.Screens) error {
+	if err := validateFilter(r.Topic); err != nil {
+		return err
+	}
+	for _, mode := range r.Modes {
+		if mode != DefaultMode && mode != LasercutterMode {
+			return fmt.Errorf("unknown mode %q", mode)
+		}
+	}
+	if r.Screen == "" && r.Action == "" {
+		return errors.New("neither screen nor action")
+	}
+	if r.Screen != "" && !screens.Has(r.Screen) {
+		return fmt.Errorf("unknown screen %q", r.Screen)
+	}
+	if _, ok := actions[r.Action]; r.Action != "" && !ok {
+		return fmt.Errorf("unknown action %q", r.Action)
+	}
+	if r.MinSeconds < 0 || r.ExpireSeconds < 0 {
+		return errors.New("negative time")
+	}
+
+	r.params = make(map[string]value)
+	for name, spec := range r.Params {
+		if name != "message" && name != "duration" {
+			return fmt.Errorf("unknown param %q", name)
+		}
+		v, err := parseValue(spec)
+		if err != nil {
+			return fmt.Errorf("param %s: %w", name, err)
+		}
+		r.params[name] = v
+	}
+
+	if c := r.When; c != nil {
+		var err error
+		if c.value, err = parseValue(c.Value); err != nil {
+			return fmt.Errorf("condition: %w", err)
+		}
+		if c.Regex != "" {
+			if c.regex, err = regexp.Compile(c.Regex); err != nil {
+				return fmt.Errorf("condition: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// matches reports whether the route takes the message.
+func (r *Route) matches(topic, payload string) bool {
+	if !matchTopic(r.Topic, topic) {
+		return false
+	}
+	c := r.When
+	if c == nil {
+		return true
+	}
+	value, ok := c.value.extract(topic, payload)
+	if !ok {
+		return false
+	}
+	if c.Equals != nil && value != *c.Equals {
+		return false
+	}
+	return c.regex == nil || c.regex.MatchString(value)
+}
+
+// values takes the params from the message.
+func (r *Route) values(topic, payload string) (routeValues, error) {
+	values := routeValues{message: payload}
+	if v, ok := r.params["message"]; ok {
+		message, ok := v.extract(topic, payload)
+		if !ok {
+			return values, errors.New("message has no value for the message param")
+		}
+		values.message = message
+	}
+	if v, ok := r.params["duration"]; ok {
+		duration, ok := v.extract(topic, payload)
+		if !ok {
+			return values, errors.New("message has no value for the duration param")
+		}
+		var err error
+		if values.duration, err = strconv.Atoi(duration); err != nil {
+			return values, fmt.Errorf("error converting duration: %w", err)
+		}
+	}
+	return values, nil
+}
+
+// returnsToIdle reports whether the idle screen follows the route's screen.
+func (r *Route) returnsToIdle() bool {
+	return r.Idle == nil || *r.Idle
+}
+
+// minDisplay returns how long the route's screen, playing for duration, is
+// shown at least.
+func (r *Route) minDisplay(duration time.Duration) time.Duration {
+	if r.MinSeconds == 0 {
+		return min(duration, maxMinDisplay)
+	}
+	return time.Duration(r.MinSeconds) * time.Second
+}
+
+// expiry returns how long the route's screen waits to be shown at most.
+func (r *Route) expiry() time.Duration {
+	if r.ExpireSeconds == 0 {
+		return defaultExpiry
+	}
+	return time.Duration(r.ExpireSeconds) * time.Second
+}
+
+// value takes a value from a message.
+type value struct {
+	kind  string
+	path  []string
+	regex *regexp.Regexp
+	level int
+}
+
+// parseValue parses where a value of a message is taken from:
+//
+//	payload          the payload, also if empty
+//	json:door.state  a field of a JSON payload, array elements by index
+//	regex:id=(\d+)   the first group of a regular expression matching the payload
+//	topic:2          a level of the topic, counted from 0
+func parseValue(spec string) (value, error) {
+	kind, arg, _ := strings.Cut(spec, ":")
+	v := value{kind: kind}
+	switch kind {
+	case "", "payload":
+		if arg != "" {
+			return v, fmt.Errorf("value %q: payload takes no argument", spec)
+		}
+		v.kind = "payload"
+	case "json":
+		if arg == "" {
+			return v, fmt.Errorf("value %q: missing path", spec)
+		}
+		v.path = strings.Split(arg, ".")
+	case "regex":
+		var err error
+		if v.regex, err = regexp.Compile(arg); err != nil {
+			return v, fmt.Errorf("value %q: %w", spec, err)
+		}
+		if v.regex.NumSubexp() == 0 {
+			return v, fmt.Errorf("value %q: regular expression has no group", spec)
+		}
+	case "topic":
+		var err error
+		if v.level, err = strconv.Atoi(arg); err != nil || v.level < 0 {
+			return v, fmt.Errorf("value %q: invalid level", spec)
+		}
+	default:
+		return v, fmt.Errorf("value %q: unknown kind %q", spec, kind)
+	}
+	return v, nil
+}
+
+// extract returns the value of the message, false if it has none.
+func (v value) extract(topic, payload string) (string, bool) {
+	switch v.kind {
+	case "json":
+		return jsonValue(payload, v.path)
+	case "regex":
+		match := v.regex.FindStringSubmatch(payload)
+		if match == nil {
+			return "", false
+		}
+		return match[1], true
+	case "topic":
+		levels := strings.Split(topic, "/")
+		if v.level >= len(levels) {
+			return "", false
+		}
+		return levels[v.level], true
+	}
+	return payload, true
+}
+
+// jsonValue returns the field of the JSON payload at the path. Objects
+// and arrays are returned as JSON, null counts as missing.
+func jsonValue(payload string, path []string) (string, bool) {
+	decoder := json.NewDecoder(strings.NewReader(payload))
+	decoder.UseNumber()
+	var current any
+	if err := decoder.Decode(&current); err != nil {
+		return "", false
+	}
+	for _, key := range path {
+		switch node := current.(type) {
+		case map[string]any:
+			current = node[key]
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return "", false
+			}
+			current = node[index]
+		default:
+			return "", false
+		}
+	}
+
+	switch node := current.(type) {
+	case nil:
+		return "", false
+	case string:
+		return node, true
+	case json.Number:
+		return node.String(), true
+	case bool:
+		return strconv.FormatBool(node), true
+	}
+	data, err := json.Marshal(current)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// validateFilter checks an MQTT topic filter.
+func validateFilter(filter string) error {
+	if filter == "" {
+		return errors.New("empty topic")
+	}
+	levels := strings.Split(filter, "/")
+	for i, level := range levels {
+		if strings.ContainsAny(level, "+#") && len(level) > 1 {
+			return fmt.Errorf("topic %q: wildcards take a whole level", filter)
+		}
+		if level == "#" && i != len(levels)-1 {
+			return fmt.Errorf("topic %q: # has to be the last level", filter)
+		}
+	}
+	return nil
+}
+
+// matchTopic reports whether the topic filter matches the topic. Wildcards
+// at the start don't match topics starting with "$", like "$SYS".
+func matchTopic(filter, topic string) bool {
+	if strings.HasPrefix(topic, "$") && (strings.HasPrefix(filter, "+") || strings.HasPrefix(filter, "#")) {
+		return false
+	}
+	filters, levels := strings.Split(filter, "/"), strings.Split(topic, "/")
+	for i, f := range filters {
+		if f == "#" {
+			return true
+		}
+		if i >= len(levels) || (f != "+" && f != levels[i]) {
+			return false
+		}
+	}
+	return len(filters) == len(levels)
+}
+
+// coversFilter reports whether filter a matches every topic b matches.
+func coversFilter(a, b string) bool {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i, level := range bs {
+		if i < len(as) && as[i] == "#" {
+			return true
+		}
+		if i >= len(as) || level == "#" || (as[i] != "+" && as[i] != level) {
+			return false
+		}
+	}
+	// "a/#" matches "a" too.
+	return len(as) == len(bs) || (len(as) == len(bs)+1 && as[len(as)-1] == "#")
+}
+
+// subscriptions returns the topic filters to subscribe for the routes. A
+// filter covered by another is left out, the client would hand its
+// messages to both subscriptions.
+func subscriptions(routes []Route) []string {
+	var filters []string
+	for _, route := range routes {
+		if !slices.Contains(filters, route.Topic) {
+			filters = append(filters, route.Topic)
+		}
+	}
+	return slices.DeleteFunc(slices.Clone(filters), func(filter string) bool {
+		return slices.ContainsFunc(filters, func(other string) bool {
+			return other != filter && coversFilter(other, filter)
+		})
+	})
+}
@@ -0,0 +1,154 @@
+package application
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMatchTopic(t *testing.T) {
+	tests := []struct {
+		filter, topic string
+		want          bool
+	}{
+		{"a/b", "a/b", true},
+		{"a/b", "a/c", false},
+		{"a/b", "a/b/c", false},
+		{"a/b/c", "a/b", false},
+		{"a/+", "a/b", true},
+		{"a/+", "a/b/c", false},
+		{"a/+", "a", false},
+		{"a/+", "a/", true},
+		{"+/b", "a/b", true},
+		{"+/+", "/b", true},
+		{"a/+/c", "a/b/c", true},
+		{"a/+/c", "a/b/d", false},
+		{"a/#", "a", true},
+		{"a/#", "a/b", true},
+		{"a/#", "a/b/c", true},
+		{"a/#", "b/a", false},
+		{"#", "a/b/c", true},
+		{"#", "/", true},
+		{"+/#", "a", true},
+		{"#", "$SYS/uptime", false},
+		{"+/uptime", "$SYS/uptime", false},
+		{"$SYS/#", "$SYS/uptime", true},
+		{"$SYS/+", "$SYS/uptime", true},
+		{"A/b", "a/b", false},
+	}
+	for _, test := range tests {
+		if got := matchTopic(test.filter, test.topic); got != test.want {
+			t.Errorf("matchTopic(%q, %q) = %t, want %t", test.filter, test.topic, got, test.want)
+		}
+	}
+}
+
+func TestCoversFilter(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"a/b", "a/b", true},
+		{"a/b", "a/c", false},
+		{"a/+", "a/b", true},
+		{"a/b", "a/+", false},
+		{"a/+", "a/+", true},
+		{"a/+", "a/b/c", false},
+		{"a/#", "a", true},
+		{"a/#", "a/b/c", true},
+		{"a/#", "a/+/c", true},
+		{"a/#", "a/#", true},
+		{"a/+/#", "a/#", false},
+		{"a/+", "a/#", false},
+		{"a/b/#", "a/#", false},
+		{"a", "a/#", false},
+		{"#", "a/#", true},
+		{"#", "+", true},
+		{"+", "#", false},
+		{"+/+", "a/b", true},
+		{"+/+", "a", false},
+		{"b/#", "a/b", false},
+	}
+	for _, test := range tests {
+		if got := coversFilter(test.a, test.b); got != test.want {
+			t.Errorf("coversFilter(%q, %q) = %t, want %t", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+// TestCoversFilterMatches checks that a covering filter matches every topic
+// the covered one does.
+func TestCoversFilterMatches(t *testing.T) {
+	filters := []string{"#", "a", "a/b", "a/+", "a/#", "+/b", "+/#", "a/+/c", "a/b/#", "+", "+/+"}
+	topics := []string{"a", "b", "a/b", "a/c", "b/b", "a/b/c", "a/d/c", "a/b/c/d", "/", "a/"}
+	for _, a := range filters {
+		for _, b := range filters {
+			if !coversFilter(a, b) {
+				continue
+			}
+			for _, topic := range topics {
+				if matchTopic(b, topic) && !matchTopic(a, topic) {
+					t.Errorf("%q covers %q, but only the latter matches %q", a, b, topic)
+				}
+			}
+		}
+	}
+}
+
+func TestSubscriptions(t *testing.T) {
+	routes := []Route{{Topic: "a/b"}, {Topic: "a/#"}, {Topic: "c/+"}, {Topic: "c/d"}, {Topic: "a/#"}, {Topic: "e"}}
+	if got, want := subscriptions(routes), []string{"a/#", "c/+", "e"}; !slices.Equal(got, want) {
+		t.Errorf("subscriptions = %q, want %q", got, want)
+	}
+}
+
+func TestValidateFilter(t *testing.T) {
+	for filter, valid := range map[string]bool{
+		"a/b": true, "a/+/c": true, "#": true, "a/#": true, "+": true, "/": true,
+		"": false, "a/#/c": false, "a+/b": false, "a/b#": false, "#/a": false,
+	} {
+		if err := validateFilter(filter); (err == nil) != valid {
+			t.Errorf("validateFilter(%q) = %v, want valid %t", filter, err, valid)
+		}
+	}
+}
+
+func TestJSONValue(t *testing.T) {
+	payload := `{"lux": 12.50, "name": "büro", "on": true, "off": null, "big": 12345678901234567890,
+		"nested": {"list": [1, {"x": "y"}], "empty": {}}}`
+	tests := []struct {
+		path string
+		want string
+		ok   bool
+	}{
+		{"lux", "12.50", true},
+		{"name", "büro", true},
+		{"on", "true", true},
+		{"off", "", false},
+		{"big", "12345678901234567890", true},
+		{"missing", "", false},
+		{"nested.list.0", "1", true},
+		{"nested.list.1.x", "y", true},
+		{"nested.list.2", "", false},
+		{"nested.list.-1", "", false},
+		{"nested.list.first", "", false},
+		{"nested.list", `[1,{"x":"y"}]`, true},
+		{"nested.empty", "{}", true},
+		{"lux.value", "", false},
+	}
+	for _, test := range tests {
+		got, ok := jsonValue(payload, strings.Split(test.path, "."))
+		if got != test.want || ok != test.ok {
+			t.Errorf("jsonValue(%q) = %q, %t, want %q, %t", test.path, got, ok, test.want, test.ok)
+		}
+	}
+
+	for _, payload := range []string{"", "not json", `{"lux": `} {
+		if got, ok := jsonValue(payload, []string{"lux"}); ok {
+			t.Errorf("jsonValue of %q = %q", payload, got)
+		}
+	}
+	if got, ok := jsonValue(`"plain"`, nil); got != "plain" || !ok {
+		t.Errorf("jsonValue of a string = %q, %t", got, ok)
+	}
+}
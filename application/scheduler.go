@@ -0,0 +1,220 @@
+package application
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+)
+
+const (
+	// screenQueueSize is the number of screens waiting to be shown, the
+	// least important one is dropped beyond.
+	screenQueueSize = 8
+
+	// maxMinDisplay caps the minimum display time taken from the duration
+	// of a screen, so a long pause doesn't hold back the screens waiting.
+	maxMinDisplay = 2 * time.Minute
+
+	// defaultExpiry is how long a screen waits to be shown before it is
+	// dropped as stale.
+	defaultExpiry = 2 * time.Minute
+)
+
+// scheduled is a screen waiting to be shown or being shown.
+type scheduled struct {
+	// board is what the screen is sent with, recording its trigger.
+	board *ledboard.Client
+	// name is the screen's name, a newer screen of the same name replaces a
+	// queued one.
+	name string
+	// screen is written to the dynamic files when it is shown, unless the
+	// screen is stored in the files of labels.
+	screen *ledboard.Screen
+	labels []string
+
+	// duration is how long the screen plays once.
+	duration   time.Duration
+	priority   int
+	minDisplay time.Duration
+	expires    time.Time
+	// idle returns to the idle screen after the screen was shown once.
+	idle bool
+}
+
+// scheduler decides which screen the sign shows. A screen of a higher
+// priority than the one shown preempts it, others wait in a queue, by
+// priority, until the one shown had its minimum display time. The idle
+// screen is shown once the queue drained. It is used by the event loop
+// only.
+type scheduler struct {
+	app *Application
+
+	// current is the screen shown, nil once it had its minimum display time
+	// and nothing is waiting.
+	current *scheduled
+	// repeating is set if current is shown until the next screen, not
+	// followed by the idle screen.
+	repeating bool
+	timer     *time.Timer
+	queue     []*scheduled
+	// idleChanged is set if the idle screen changed while current was shown.
+	idleChanged bool
+}
+
+func newScheduler(app *Application) *scheduler {
+	return &scheduler{app: app}
+}
+
+// submit shows the screen right away if the sign is free or the screen is
+// more important than the one shown, else queues it.
+func (s *scheduler) submit(item *scheduled) error {
+	if s.current == nil {
+		return s.show(item)
+	}
+	if item.priority > s.current.priority {
+		slog.Debug("screen preempts the one shown", "screen", item.name, "preempted", s.current.name)
+		// The preempted screen didn't have its minimum display time, it
+		// starts over once it is its turn again.
+		s.expire(time.Now())
+		if s.makeRoom(s.current.priority) {
+			s.insert(s.current, true)
+		}
+		return s.show(item)
+	}
+	return s.enqueue(item)
+}
+
+// showIdle shows the idle screen, now the laser operation screen or
+// back to the normal one, unless a screen is shown. Then it follows that.
+func (s *scheduler) showIdle(board *ledboard.Client) error {
+	if s.current != nil {
+		s.idleChanged = true
+		return nil
+	}
+	s.app.timeline.showIdle(s.app.idleScreen(), time.Now())
+	return board.SetRunSequence(s.app.getIdleFile())
+}
+
+// show sends the screen, followed by the idle screen if nothing is waiting.
+func (s *scheduler) show(item *scheduled) error {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.current = item
+	s.repeating = !item.idle || len(s.queue) > 0
+	s.timer = time.AfterFunc(item.minDisplay, func() { s.app.post(elapsedEvent{item: item}) })
+	return s.send(item)
+}
+
+// resend shows the screen shown again, or the idle screen if there is none,
+// after the files of the sign were written anew. Allocating the memory
+// erases the dynamic files.
+func (s *scheduler) resend(board *ledboard.Client) error {
+	if s.current == nil {
+		s.app.timeline.showIdle(s.app.idleScreen(), time.Now())
+		return board.SetRunSequence(s.app.getIdleFile())
+	}
+	return s.send(s.current)
+}
+
+// send writes the screen and starts it on the sign, from the beginning.
+func (s *scheduler) send(item *scheduled) error {
+	s.app.timeline.show(item.name, time.Now(), item.duration, s.repeating)
+
+	labels := item.labels
+	if item.screen != nil {
+		var err error
+		if labels, err = s.app.writeDynamic(item.board, item.screen); err != nil {
+			return err
+		}
+	}
+	if s.repeating {
+		return item.board.SetRunSequence(labels...)
+	}
+	return s.app.show(item.board, labels...)
+}
+
+// elapsed shows the next screen once the item had its minimum display
+// time, unless it was replaced meanwhile.
+func (s *scheduler) elapsed(item *scheduled) {
+	if s.current != item {
+		return
+	}
+
+	s.expire(time.Now())
+	if len(s.queue) > 0 {
+		next := s.queue[0]
+		s.queue = s.queue[1:]
+		if err := s.show(next); err != nil {
+			slog.Error("failed to show queued screen", "screen", next.name, "error", err)
+		}
+		return
+	}
+
+	s.current = nil
+	// The screen is repeated since something was waiting that expired or
+	// the idle screen changed meanwhile, the sign won't go to idle itself.
+	if (s.repeating && item.idle) || s.idleChanged {
+		if err := item.board.SetRunSequence(s.app.getIdleFile()); err != nil {
+			slog.Error("failed to show idle screen", "error", err)
+		}
+		s.app.timeline.showIdle(s.app.idleScreen(), time.Now())
+	}
+	s.idleChanged = false
+}
+
+// enqueue queues the screen, replacing a queued one of the same name.
+func (s *scheduler) enqueue(item *scheduled) error {
+	s.expire(time.Now())
+	s.queue = slices.DeleteFunc(s.queue, func(queued *scheduled) bool { return queued.name == item.name })
+
+	if !s.makeRoom(item.priority) {
+		return fmt.Errorf("screen queue is full, dropping %s", item.name)
+	}
+	s.insert(item, false)
+	slog.Debug("screen queued", "screen", item.name, "priority", item.priority, "waiting", len(s.queue))
+	return nil
+}
+
+// makeRoom drops the oldest of the least important queued screens if the
+// queue is full, unless they are more important than priority. It reports
+// whether there is room.
+func (s *scheduler) makeRoom(priority int) bool {
+	if len(s.queue) < screenQueueSize {
+		return true
+	}
+	lowest := s.queue[len(s.queue)-1].priority
+	if lowest > priority {
+		return false
+	}
+	oldest := slices.IndexFunc(s.queue, func(queued *scheduled) bool { return queued.priority == lowest })
+	slog.Warn("screen queue is full, dropping screen", "screen", s.queue[oldest].name)
+	s.queue = slices.Delete(s.queue, oldest, oldest+1)
+	return true
+}
+
+// insert adds the screen to the queue after the screens of a higher
+// priority, and after those of the same priority unless first is set.
+func (s *scheduler) insert(item *scheduled, first bool) {
+	i := slices.IndexFunc(s.queue, func(queued *scheduled) bool {
+		return queued.priority < item.priority || (first && queued.priority == item.priority)
+	})
+	if i < 0 {
+		i = len(s.queue)
+	}
+	s.queue = slices.Insert(s.queue, i, item)
+}
+
+// expire drops the queued screens that waited too long.
+func (s *scheduler) expire(now time.Time) {
+	s.queue = slices.DeleteFunc(s.queue, func(queued *scheduled) bool {
+		if now.After(queued.expires) {
+			slog.Info("dropping stale screen", "screen", queued.name)
+			return true
+		}
+		return false
+	})
+}
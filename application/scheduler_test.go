@@ -0,0 +1,232 @@
+package application
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+)
+
+// afterAllocation returns the packets received after the last memory
+// configuration.
+func afterAllocation(packets []*ledboard.Packet) []*ledboard.Packet {
+	last := -1
+	for i, packet := range packets {
+		if packet.Function == ledboard.FunctionMemory && packet.Command == ledboard.CommandSpecialFunction {
+			last = i
+		}
+	}
+	if last < 0 {
+		return nil
+	}
+	return packets[last+1:]
+}
+
+// lastSequence returns the last run sequence of the packets, nil if none.
+func lastSequence(packets []*ledboard.Packet) []string {
+	for i := len(packets) - 1; i >= 0; i-- {
+		if packets[i].Function == ledboard.FunctionRunSequence {
+			return packets[i].Sequence
+		}
+	}
+	return nil
+}
+
+func TestUploadFilesShowsCurrentScreen(t *testing.T) {
+	quiet(t)
+	tests := map[string]struct {
+		item *scheduled
+		want []string
+	}{
+		"dynamic": {
+			item: &scheduled{name: "alarm", priority: 4, idle: true},
+			want: []string{dynamicFile, idleFile},
+		},
+		"stored": {
+			item: &scheduled{name: "pizza", labels: []string{pizzaFile}, priority: 2, idle: true},
+			want: []string{pizzaFile, idleFile},
+		},
+		"repeating": {
+			item: &scheduled{name: "now-playing", priority: 1},
+			want: []string{dynamicFile},
+		},
+		"idle": {
+			want: []string{idleFile},
+		},
+	}
+
+	for name, test := range tests {
+		for _, event := range []event{screensChangedEvent{}, pushStateEvent{}} {
+			t.Run(fmt.Sprintf("%s/%T", name, event), func(t *testing.T) {
+				app, sign := newTestApp(t, DefaultMode)
+				screen := "idle"
+				if test.item != nil {
+					item := new(scheduled)
+					*item = *test.item
+					item.board, item.duration, item.minDisplay = app.ledBoardClient, time.Minute, time.Hour
+					item.expires = time.Now().Add(time.Minute)
+					if item.labels == nil {
+						item.screen = app.screens.Alarm("Fire in the hole")
+					}
+					if err := app.scheduler.submit(item); err != nil {
+						t.Fatal(err)
+					}
+					screen = item.name
+				}
+
+				app.dispatch(event)
+
+				if state := app.State(); state.Screen != screen {
+					t.Errorf("state shows %q, want %q", state.Screen, screen)
+				}
+				sign.waitFor(t, func(packets []*ledboard.Packet) bool {
+					return slices.Equal(lastSequence(afterAllocation(packets)), test.want)
+				})
+				if test.item != nil && test.item.labels == nil {
+					// The dynamic file was written again after the
+					// allocation erased it.
+					packets := sign.waitFor(t, func(packets []*ledboard.Packet) bool { return true })
+					if !slices.ContainsFunc(afterAllocation(packets), func(p *ledboard.Packet) bool {
+						return p.Command == ledboard.CommandWriteText && p.Label == dynamicFile
+					}) {
+						t.Errorf("dynamic file not written after the allocation")
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestSchedulerQueue(t *testing.T) {
+	quiet(t)
+	type step struct {
+		// submit names the screen submitted, elapse the one whose minimum
+		// display time is over.
+		submit   string
+		priority int
+		stale    bool
+		elapse   string
+		err      bool
+
+		current string
+		queue   []string
+	}
+	names := func(from, to int) []string {
+		var names []string
+		for i := from; i < to; i++ {
+			names = append(names, fmt.Sprintf("q%d", i))
+		}
+		return names
+	}
+	// fill queues the screens q0 to q7 while a is shown.
+	fill := func(priority int) []step {
+		var steps []step
+		for i := range screenQueueSize {
+			steps = append(steps, step{submit: fmt.Sprintf("q%d", i), priority: priority, current: "a", queue: names(0, i+1)})
+		}
+		return steps
+	}
+
+	tests := map[string][]step{
+		"shown right away": {
+			{submit: "a", priority: 1, current: "a"},
+			{elapse: "a"},
+		},
+		"waiting": {
+			{submit: "a", priority: 2, current: "a"},
+			{submit: "b", priority: 1, current: "a", queue: []string{"b"}},
+			{submit: "c", priority: 2, current: "a", queue: []string{"c", "b"}},
+			{elapse: "a", current: "c", queue: []string{"b"}},
+			{elapse: "c", current: "b"},
+			{elapse: "b"},
+		},
+		"in order of submission": {
+			{submit: "a", priority: 1, current: "a"},
+			{submit: "b", priority: 1, current: "a", queue: []string{"b"}},
+			{submit: "c", priority: 1, current: "a", queue: []string{"b", "c"}},
+			{submit: "d", priority: 0, current: "a", queue: []string{"b", "c", "d"}},
+			{submit: "e", priority: 1, current: "a", queue: []string{"b", "c", "e", "d"}},
+		},
+		"replacing a queued screen": {
+			{submit: "a", priority: 3, current: "a"},
+			{submit: "b", priority: 1, current: "a", queue: []string{"b"}},
+			{submit: "c", priority: 1, current: "a", queue: []string{"b", "c"}},
+			{submit: "b", priority: 1, current: "a", queue: []string{"c", "b"}},
+			{submit: "c", priority: 2, current: "a", queue: []string{"c", "b"}},
+		},
+		"preemption": {
+			{submit: "a", priority: 1, current: "a"},
+			{submit: "c", priority: 1, current: "a", queue: []string{"c"}},
+			{submit: "b", priority: 2, current: "b", queue: []string{"a", "c"}},
+			{submit: "d", priority: 3, current: "d", queue: []string{"b", "a", "c"}},
+			{elapse: "d", current: "b", queue: []string{"a", "c"}},
+			{elapse: "b", current: "a", queue: []string{"c"}},
+		},
+		"replaced screen elapsing": {
+			{submit: "a", priority: 1, current: "a"},
+			{submit: "b", priority: 2, current: "b", queue: []string{"a"}},
+			{elapse: "a", current: "b", queue: []string{"a"}},
+		},
+		"stale screens": {
+			{submit: "a", priority: 2, current: "a"},
+			{submit: "b", priority: 1, stale: true, current: "a", queue: []string{"b"}},
+			{submit: "c", priority: 1, current: "a", queue: []string{"c"}},
+			{submit: "d", priority: 1, stale: true, current: "a", queue: []string{"c", "d"}},
+			{elapse: "a", current: "c"},
+			{elapse: "c"},
+		},
+		"stale screen preempted": {
+			{submit: "a", priority: 1, stale: true, current: "a"},
+			{submit: "b", priority: 2, current: "b", queue: []string{"a"}},
+			{elapse: "b"},
+		},
+		"full queue": append(append([]step{{submit: "a", priority: 5, current: "a"}}, fill(1)...),
+			step{submit: "x", priority: 2, current: "a", queue: append([]string{"x"}, names(1, 8)...)},
+			step{submit: "y", priority: 0, err: true, current: "a", queue: append([]string{"x"}, names(1, 8)...)},
+			step{submit: "z", priority: 1, current: "a", queue: append(append([]string{"x"}, names(2, 8)...), "z")},
+		),
+		"full queue preempted": append(append([]step{{submit: "a", priority: 1, current: "a"}}, fill(1)...),
+			step{submit: "b", priority: 2, current: "b", queue: append([]string{"a"}, names(1, 8)...)},
+		),
+	}
+
+	for name, steps := range tests {
+		t.Run(name, func(t *testing.T) {
+			app, _ := newTestApp(t, DefaultMode)
+			items := make(map[string]*scheduled)
+			for i, step := range steps {
+				if step.submit != "" {
+					item := &scheduled{
+						board: app.ledBoardClient, name: step.submit, labels: []string{pizzaFile},
+						priority: step.priority, duration: time.Minute, minDisplay: time.Hour,
+						expires: time.Now().Add(time.Minute), idle: true,
+					}
+					if step.stale {
+						item.expires = time.Now().Add(-time.Second)
+					}
+					items[step.submit] = item
+					if err := app.scheduler.submit(item); (err != nil) != step.err {
+						t.Fatalf("step %d: submit %s: %v", i, step.submit, err)
+					}
+				}
+				if step.elapse != "" {
+					app.scheduler.elapsed(items[step.elapse])
+				}
+
+				current := ""
+				if app.scheduler.current != nil {
+					current = app.scheduler.current.name
+				}
+				var queue []string
+				for _, queued := range app.scheduler.queue {
+					queue = append(queue, queued.name)
+				}
+				if current != step.current || !slices.Equal(queue, step.queue) {
+					t.Fatalf("step %d: showing %q, waiting %q, want %q, %q", i, current, queue, step.current, step.queue)
+				}
+			}
+		})
+	}
+}
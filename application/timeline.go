@@ -0,0 +1,140 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+)
+
+// State is what the sign shows.
+type State struct {
+	// Screen is the name of the screen shown, like "alarm" or "idle".
+	Screen string `json:"screen"`
+	// Since is when the screen was sent, or when the sign returned to idle.
+	Since time.Time `json:"since"`
+	// IdleAt is when the sign is expected to show the idle screen again,
+	// nil while it does or if the screen is shown until the next one.
+	IdleAt *time.Time `json:"idleAt,omitempty"`
+}
+
+// timeline follows what the sign shows from the screens sent and their
+// expected duration.
+type timeline struct {
+	mu     sync.Mutex
+	screen string
+	since  time.Time
+	// idleAt is when the screen ends, zero if it repeats.
+	idleAt time.Time
+	// idle is the name of the idle screen the sign returns to.
+	idle string
+
+	changed chan struct{}
+}
+
+func newTimeline() *timeline {
+	return &timeline{screen: "idle", idle: "idle", changed: make(chan struct{}, 1)}
+}
+
+// show records that the screen was sent at now. It plays for duration,
+// then the idle screen follows, unless it repeats.
+func (t *timeline) show(screen string, now time.Time, duration time.Duration, repeats bool) {
+	t.mu.Lock()
+	t.screen, t.since, t.idleAt = screen, now, time.Time{}
+	if !repeats {
+		t.idleAt = now.Add(duration)
+	}
+	t.mu.Unlock()
+	t.notify()
+}
+
+// showIdle records that the idle screen of the given name was sent at now.
+func (t *timeline) showIdle(idle string, now time.Time) {
+	t.mu.Lock()
+	t.screen, t.since, t.idleAt, t.idle = idle, now, time.Time{}, idle
+	t.mu.Unlock()
+	t.notify()
+}
+
+func (t *timeline) notify() {
+	select {
+	case t.changed <- struct{}{}:
+	default:
+	}
+}
+
+// state returns what the sign shows at now.
+func (t *timeline) state(now time.Time) State {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.idleAt.IsZero() && !now.Before(t.idleAt) {
+		return State{Screen: t.idle, Since: t.idleAt}
+	}
+	state := State{Screen: t.screen, Since: t.since}
+	if !t.idleAt.IsZero() {
+		idleAt := t.idleAt
+		state.IdleAt = &idleAt
+	}
+	return state
+}
+
+// State returns what the LED board shows right now and when it is expected
+// to show the idle screen again.
+func (app *Application) State() State {
+	return app.timeline.state(time.Now())
+}
+
+// idleScreen returns the name of the idle screen, see getIdleFile.
+func (app *Application) idleScreen() string {
+	if app.getIdleFile() == laserOperationFile {
+		return "laser-operation"
+	}
+	return "idle"
+}
+
+// runState logs what the sign shows and publishes it to the state topic
+// whenever it changes, until the context is done.
+func (app *Application) runState(ctx context.Context) {
+	var last State
+	for {
+		state := app.timeline.state(time.Now())
+		if state.Screen != last.Screen || !state.Since.Equal(last.Since) {
+			slog.Debug("LED board shows", "screen", state.Screen, "idleAt", state.IdleAt)
+			app.publishState(state)
+			last = state
+		}
+
+		// Without a screen ending the timer never fires.
+		wait := time.Duration(math.MaxInt64)
+		if state.IdleAt != nil {
+			wait = time.Until(*state.IdleAt)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-app.timeline.changed:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+func (app *Application) publishState(state State) {
+	topic := app.management.StateTopic
+	if topic == "" {
+		return
+	}
+	payload, err := json.Marshal(state)
+	if err != nil {
+		slog.Error("failed to encode LED board state", "error", err)
+		return
+	}
+	if err := app.mqttClient.Publish(topic, true, payload); err != nil {
+		slog.Error("failed to publish LED board state", "error", err)
+	}
+}
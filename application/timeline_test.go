@@ -0,0 +1,111 @@
+package application
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeline(t *testing.T) {
+	start := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+	idleAt := func(seconds int) *time.Time {
+		t := at(seconds)
+		return &t
+	}
+
+	type check struct {
+		at   int
+		want State
+	}
+	tests := []struct {
+		name   string
+		record func(t *timeline)
+		checks []check
+	}{
+		{
+			name:   "idle from the start",
+			record: func(t *timeline) {},
+			checks: []check{{at: 0, want: State{Screen: "idle"}}},
+		},
+		{
+			name:   "screen followed by idle",
+			record: func(t *timeline) { t.show("alarm", at(0), 30*time.Second, false) },
+			checks: []check{
+				{at: 0, want: State{Screen: "alarm", Since: at(0), IdleAt: idleAt(30)}},
+				{at: 29, want: State{Screen: "alarm", Since: at(0), IdleAt: idleAt(30)}},
+				{at: 30, want: State{Screen: "idle", Since: at(30)}},
+				{at: 90, want: State{Screen: "idle", Since: at(30)}},
+			},
+		},
+		{
+			name:   "repeating screen",
+			record: func(t *timeline) { t.show("now-playing", at(0), 10*time.Second, true) },
+			checks: []check{
+				{at: 5, want: State{Screen: "now-playing", Since: at(0)}},
+				{at: 3600, want: State{Screen: "now-playing", Since: at(0)}},
+			},
+		},
+		{
+			name: "screen replaced",
+			record: func(t *timeline) {
+				t.show("alarm", at(0), 30*time.Second, false)
+				t.show("doorbell", at(10), 10*time.Second, false)
+			},
+			checks: []check{
+				{at: 15, want: State{Screen: "doorbell", Since: at(10), IdleAt: idleAt(20)}},
+				{at: 25, want: State{Screen: "idle", Since: at(20)}},
+			},
+		},
+		{
+			name: "back to the laser operation",
+			record: func(t *timeline) {
+				t.showIdle("laser-operation", at(0))
+				t.show("pizza", at(10), 5*time.Second, false)
+			},
+			checks: []check{
+				{at: 12, want: State{Screen: "pizza", Since: at(10), IdleAt: idleAt(15)}},
+				{at: 15, want: State{Screen: "laser-operation", Since: at(15)}},
+			},
+		},
+		{
+			name: "idle sent before the screen ended",
+			record: func(t *timeline) {
+				t.show("alarm", at(0), 30*time.Second, false)
+				t.showIdle("idle", at(10))
+			},
+			checks: []check{{at: 20, want: State{Screen: "idle", Since: at(10)}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tl := newTimeline()
+			test.record(tl)
+			for _, check := range test.checks {
+				got := tl.state(at(check.at))
+				if got.Screen != check.want.Screen || !got.Since.Equal(check.want.Since) ||
+					(got.IdleAt == nil) != (check.want.IdleAt == nil) ||
+					(got.IdleAt != nil && !got.IdleAt.Equal(*check.want.IdleAt)) {
+					t.Errorf("state %ds in = %+v, want %+v", check.at, got, check.want)
+				}
+			}
+		})
+	}
+}
+
+func TestTimelineNotifies(t *testing.T) {
+	tl := newTimeline()
+	tl.show("alarm", time.Now(), time.Second, false)
+	tl.showIdle("idle", time.Now())
+	// Changes are coalesced into a single signal.
+	select {
+	case <-tl.changed:
+	default:
+		t.Fatal("no change signalled")
+	}
+	select {
+	case <-tl.changed:
+		t.Error("change signalled twice")
+	default:
+	}
+}
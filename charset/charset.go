@@ -0,0 +1,171 @@
+// Package charset maps arbitrary Unicode text to the characters the LED
+// board's fonts can render.
+package charset
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Printable reports whether the sign's builtin fonts have a glyph for the
+// byte. They cover printable ASCII only, other bytes are control codes or
+// render as garbage.
+func Printable(b byte) bool {
+	return b >= 0x20 && b <= 0x7E
+}
+
+// Transliterate replaces every character the sign's builtin fonts can't
+// render, see TransliterateFor.
+func Transliterate(text string) string {
+	return TransliterateFor(text, nil)
+}
+
+// TransliterateFor replaces every character that is neither printable ASCII
+// nor supported by the target font, like a custom font holding umlauts.
+// Replacement is deterministic:
+//
+//   - German umlauts become two letters, ß becomes "ss"
+//   - typographic punctuation, ligatures and symbols get ASCII spellings
+//   - emoji become short text like ":)" and are dropped if unknown
+//   - Greek and Cyrillic letters are romanized
+//   - other letters lose their accents
+//   - whitespace becomes a space, invisible characters are dropped
+//   - anything else becomes a question mark
+//
+// Umlauts written as a vowel followed by a combining diaeresis are taken as
+// the single letter.
+func TransliterateFor(text string, supported func(rune) bool) string {
+	var sb strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if umlaut, ok := umlauts[r]; ok && i+1 < len(runes) && runes[i+1] == combiningDiaeresis {
+			r = umlaut
+			i++
+		}
+		if supported != nil && supported(r) {
+			sb.WriteRune(r)
+			continue
+		}
+		sb.WriteString(transliterate(r))
+	}
+	return sb.String()
+}
+
+func transliterate(r rune) string {
+	if r < 0x80 && Printable(byte(r)) {
+		return string(r)
+	}
+	if s, ok := replacements[r]; ok {
+		return s
+	}
+	if s, ok := emoji[r]; ok {
+		return s
+	}
+	if s, ok := romanizations[r]; ok {
+		return s
+	}
+	if s, ok := romanizations[unicode.ToLower(r)]; ok {
+		return capitalize(s)
+	}
+	if base, ok := decompositions[r]; ok {
+		return transliterate(base)
+	}
+
+	switch {
+	case r >= 0xFF01 && r <= 0xFF5E:
+		// Fullwidth forms of ASCII.
+		return string(r - 0xFF01 + '!')
+	case unicode.IsSpace(r):
+		return " "
+	case unicode.Is(unicode.Mn, r), unicode.Is(unicode.Cf, r), unicode.IsControl(r),
+		r >= 0xFE00 && r <= 0xFE0F, r >= 0x1F3FB && r <= 0x1F3FF:
+		// Combining marks, zero width joiners, variation selectors and skin
+		// tones only modify their neighbours.
+		return ""
+	case isEmoji(r):
+		return ""
+	}
+	return "?"
+}
+
+// capitalize uppercases the first letter of a romanization, so Ж becomes Zh.
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func isEmoji(r rune) bool {
+	return r >= 0x1F000 && r <= 0x1FAFF || r >= 0x2600 && r <= 0x27BF
+}
+
+// combiningDiaeresis follows a vowel in the decomposed form of an umlaut.
+const combiningDiaeresis = '\u0308'
+
+// umlauts are the umlauts composed from a vowel and combiningDiaeresis.
+var umlauts = map[rune]rune{'A': 'Ä', 'O': 'Ö', 'U': 'Ü', 'a': 'ä', 'o': 'ö', 'u': 'ü'}
+
+// replacements spells out letters without a decomposition and symbols. It is
+// consulted before the decomposition, so umlauts aren't just stripped.
+var replacements = map[rune]string{
+	// German
+	'Ä': "Ae", 'Ö': "Oe", 'Ü': "Ue", 'ä': "ae", 'ö': "oe", 'ü': "ue", 'ß': "ss", 'ẞ': "SS",
+
+	// Letters with strokes and ligatures
+	'Æ': "AE", 'æ': "ae", 'Œ': "OE", 'œ': "oe", 'Ø': "O", 'ø': "o", 'Đ': "D", 'đ': "d",
+	'Ð': "D", 'ð': "d", 'Þ': "Th", 'þ': "th", 'Ł': "L", 'ł': "l", 'Ħ': "H", 'ħ': "h",
+	'ı': "i", 'Ŋ': "N", 'ŋ': "n", 'ſ': "s", 'Ĳ': "IJ", 'ĳ': "ij", 'ﬀ': "ff", 'ﬁ': "fi",
+	'ﬂ': "fl", 'ﬃ': "ffi", 'ﬄ': "ffl",
+
+	// Punctuation
+	'‘': "'", '’': "'", '‚': "'", '‛': "'", '′': "'", '´': "'",
+	'“': "\"", '”': "\"", '„': "\"", '‟': "\"", '″': "\"", '«': "<<", '»': ">>",
+	'‹': "<", '›': ">", '‐': "-", '‑': "-", '‒': "-", '–': "-", '—': "-", '―': "-",
+	'−': "-", '…': "...", '•': "*", '·': ".", '¡': "!", '¿': "?", '¦': "|", '§': "S",
+	'¶': "P", '†': "+", '‡': "++", '‰': "%o", '⁄': "/", '∕': "/",
+
+	// Symbols
+	'€': "EUR", '£': "GBP", '¥': "JPY", '¢': "c", '©': "(c)", '®': "(R)",
+	'™': "TM", '°': "deg", '±': "+-", '×': "x", '÷': "/", '¹': "1", '²': "2",
+	'³': "3", '¼': "1/4", '½': "1/2", '¾': "3/4", 'µ': "u", '¬': "!", '¤': "?",
+	'ª': "a", 'º': "o", '←': "<-", '→': "->", '↑': "^", '↓': "v", '↔': "<->",
+	'⇐': "<=", '⇒': "=>", '≤': "<=", '≥': ">=", '≠': "!=", '≈': "~", '∞': "inf",
+}
+
+// emoji maps common emoji to short text, unknown emoji are dropped.
+var emoji = map[rune]string{
+	'☺': ":)", '🙂': ":)", '😊': ":)", '😇': ":)", '😌': ":)", '🤗': ":)",
+	'😀': ":D", '😃': ":D", '😄': ":D", '😁': ":D", '😆': ":D", '😅': ":D",
+	'😂': ":'D", '🤣': ":'D", '😉': ";)", '😜': ";P", '😛': ":P", '😝': ":P",
+	'🙁': ":(", '☹': ":(", '😞': ":(", '😟': ":(", '😕': ":/", '😐': ":|",
+	'😢': ":'(", '😭': ":'(", '😮': ":O", '😲': ":O", '😱': ":O", '😠': ">:(",
+	'😡': ">:(", '😎': "B)", '😘': ":*", '😍': "<3", '🥰': "<3", '❤': "<3",
+	'♥': "<3", '💖': "<3", '💕': "<3", '💜': "<3", '💙': "<3", '💚': "<3",
+	'💛': "<3", '🧡': "<3", '🖤': "<3", '💔': "</3", '👍': "+1", '👎': "-1",
+	'👋': "o/", '🙏': "_/\\_", '✓': "v", '✔': "v", '✗': "x", '✘': "x",
+	'❌': "x", '★': "*", '☆': "*", '⭐': "*", '🌟': "*", '✨': "*",
+	'🎵': "~", '🎶': "~~", '♪': "~", '♫': "~~", '🔥': "!", '⚡': "!",
+	'⚠': "(!)", '❗': "!", '❓': "?", '💯': "100", '🍕': "(pizza)", '🍺': "(beer)",
+	'🔔': "(bell)", '🚪': "(door)", '🎉': "\\o/", '🥳': "\\o/", '🤖': "[o_o]", '👀': "o.o",
+}
+
+// romanizations spells Greek and Cyrillic letters in Latin script, after
+// ELOT 743 and the common scholarly transliteration. Uppercase letters
+// without an entry use the capitalized lowercase entry.
+var romanizations = map[rune]string{
+	// Greek
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th",
+	'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p",
+	'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps",
+	'ω': "o",
+
+	// Cyrillic
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g", 'ў': "u", 'ђ': "dj", 'ј': "j",
+	'љ': "lj", 'њ': "nj", 'ћ': "c", 'џ': "dz", 'ѕ': "dz", 'ѓ': "gj", 'ќ': "kj",
+}
@@ -0,0 +1,78 @@
+package charset
+
+import "testing"
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"ascii", "Hello, World! 123 ~", "Hello, World! 123 ~"},
+		{"umlauts", "Äpfel Öl Übel ärgern öde über", "Aepfel Oel Uebel aergern oede ueber"},
+		{"sharp s", "Straße STRAẞE", "Strasse STRASSE"},
+		{"ligatures", "Æsir œuvre ﬁne", "AEsir oeuvre fine"},
+		{"punctuation", "„Hallo“ – ‚Welt‘ … «ok»", "\"Hallo\" - 'Welt' ... <<ok>>"},
+		{"symbols", "5 € · 20 °C ≥ 3 × 2", "5 EUR . 20 degC >= 3 x 2"},
+		{"emoji", "Pizza 🍕 ist da 🙂", "Pizza (pizza) ist da :)"},
+		{"unknown emoji", "ok 🦩☃", "ok "},
+		{"skin tone and joiner", "👋🏽‍", "o/"},
+		{"greek", "Καλημέρα", "Kalimera"},
+		{"cyrillic", "Жёлтый Щука", "Zheltyy Shchuka"},
+		{"accents", "Crème brûlée à São Paulo, Łódź", "Creme brulee a Sao Paulo, Lodz"},
+		{"whitespace", "a\tb\u00a0c\u2003d", "a b c d"},
+		{"invisible", "a\u200bb\ufe0fc", "abc"},
+		{"fullwidth", "ＡＢＣ！", "ABC!"},
+		{"unknown", "漢字 ∂", "?? ?"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Transliterate(test.text); got != test.want {
+				t.Errorf("Transliterate(%q) = %q, want %q", test.text, got, test.want)
+			}
+		})
+	}
+}
+
+func TestTransliterateFor(t *testing.T) {
+	// A custom font with umlauts keeps them, others are still replaced.
+	supported := func(r rune) bool { return r == 'ä' || r == 'ö' || r == 'ü' }
+	if got, want := TransliterateFor("Tür öffnen, Straße", supported), "Tür öffnen, Strasse"; got != want {
+		t.Errorf("TransliterateFor = %q, want %q", got, want)
+	}
+	if got, want := TransliterateFor("Tür", nil), "Tuer"; got != want {
+		t.Errorf("TransliterateFor without font = %q, want %q", got, want)
+	}
+}
+
+func TestPrintable(t *testing.T) {
+	for b := 0; b < 0x100; b++ {
+		if got, want := Printable(byte(b)), b >= ' ' && b <= '~'; got != want {
+			t.Errorf("Printable(%#x) = %v, want %v", b, got, want)
+		}
+	}
+}
+
+func TestTransliterateDecomposed(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Grüße", "Gruesse"},
+		{"Überall äöü", "Ueberall aeoeue"},
+		{"Citroën", "Citroen"},
+		{"̈u", "u"},
+		{"ü̈", "ue"},
+	}
+	for _, test := range tests {
+		if got := Transliterate(test.text); got != test.want {
+			t.Errorf("Transliterate(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+
+	// A font having the umlaut gets the composed letter.
+	supported := func(r rune) bool { return r == 'ü' }
+	if got, want := TransliterateFor("Müller", supported), "Müller"; got != want {
+		t.Errorf("TransliterateFor = %q, want %q", got, want)
+	}
+}
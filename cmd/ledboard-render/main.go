@@ -0,0 +1,188 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/emulator"
+	"github.com/b4ckspace/ledboard-v2/graphics"
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+	"github.com/b4ckspace/ledboard-v2/pixelfont"
+	"github.com/b4ckspace/ledboard-v2/screens"
+)
+
+func main() {
+	screenName := flag.String("screen", "idle", "screen to render (alarm, donation, doorbell, idle, laser-finished, laser-operation, new-member, now-playing, pizza, psa, text, picture)")
+	arg := flag.String("arg", "", "argument of the screen, like the message or the member count")
+	datagramFile := flag.String("datagram", "", "render a raw datagram from this file instead of a screen")
+	width := flag.Int("width", 160, "board width in pixels")
+	height := flag.Int("height", 16, "board height in pixels")
+	format := flag.String("format", "ansi", "output format (ansi, png, gif, frames)")
+	out := flag.String("out", "", "output file, or directory for frames")
+	scale := flag.Int("scale", 4, "image pixels per LED")
+	textFont := flag.String("text-font", "normal-7x6", "font of the text screen")
+	dither := flag.Bool("dither", false, "dither the image of the picture screen")
+	templates := flag.String("screens", "", "template file defining the screens, the builtin screens if empty")
+	fonts := ledboard.NewFontSet()
+	flag.Func("font", "upload a font into a custom slot, e.g. custom-3=compact-3x5 or custom-4=font.bdf, repeatable", func(value string) error {
+		slotName, source, ok := strings.Cut(value, "=")
+		if !ok {
+			return fmt.Errorf("expected slot=font")
+		}
+		slot, err := ledboard.ParseFont(slotName)
+		if err != nil {
+			return err
+		}
+		font, err := pixelfont.Open(source)
+		if err != nil {
+			return err
+		}
+		return fonts.Assign(slot, font)
+	})
+	flag.Parse()
+
+	board := emulator.NewBoard(*width, *height)
+	board.Fonts = fonts.Fonts()
+	// The idle screen shows the member count from a string file.
+	board.Strings[screens.MemberCountString] = *arg
+
+	var screen *ledboard.Screen
+	var err error
+	switch *screenName {
+	case "text":
+		screen, err = textScreen(*arg, *textFont, fonts)
+	case "picture":
+		screen, err = pictureScreen(board, *arg, *dither)
+	default:
+		s := screens.NewScreens(screens.Board{Width: *width, Height: *height}, fonts)
+		if *templates != "" {
+			err = s.Load(*templates)
+		}
+		if err == nil {
+			screen, err = loadScreen(s, *screenName, *arg, *datagramFile)
+		}
+	}
+	if err != nil {
+		slog.Error("unable to load screen", "error", err)
+		os.Exit(1)
+	}
+	steps := board.Render(screen, emulator.RenderOptions{Clock: time.Now()})
+
+	switch *format {
+	case "ansi":
+		err = playANSI(steps)
+	case "png":
+		err = writeFile(*out, func(f *os.File) error { return emulator.WritePNG(f, steps[len(steps)/2].Matrix, *scale) })
+	case "gif":
+		err = writeFile(*out, func(f *os.File) error { return emulator.WriteGIF(f, steps, *scale) })
+	case "frames":
+		err = emulator.WritePNGFrames(*out, steps, *scale)
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		slog.Error("unable to render screen", "error", err)
+		os.Exit(1)
+	}
+}
+
+func loadScreen(s *screens.Screens, name, arg, datagramFile string) (*ledboard.Screen, error) {
+	if datagramFile != "" {
+		datagram, err := os.ReadFile(datagramFile)
+		if err != nil {
+			return nil, err
+		}
+		return emulator.DecodeScreen(string(datagram))
+	}
+
+	number := func() int {
+		n, _ := strconv.Atoi(arg)
+		return n
+	}
+
+	switch name {
+	case "alarm":
+		return s.Alarm(arg), nil
+	case "donation":
+		return s.Donation(), nil
+	case "doorbell":
+		return s.DoorBell(), nil
+	case "idle":
+		return s.Idle(), nil
+	case "laser-finished":
+		return s.LaserFinished(number()), nil
+	case "laser-operation":
+		return s.LaserOperation(), nil
+	case "new-member":
+		return s.NewMemberRegistration(arg), nil
+	case "now-playing":
+		return s.NowPlaying(arg), nil
+	case "pizza":
+		return s.PizzaTimer(), nil
+	case "psa":
+		return s.PublicServiceAnnouncement(arg), nil
+	}
+	return nil, fmt.Errorf("unknown screen %q", name)
+}
+
+// textScreen shows the text in a single font, to try out fonts.
+func textScreen(text, fontName string, fonts *ledboard.FontSet) (*ledboard.Screen, error) {
+	font, err := ledboard.ParseFont(fontName)
+	if err != nil {
+		return nil, err
+	}
+	screen := ledboard.NewScreen()
+	screen.AddFrame().Font(font).Text(fonts.Text(font, text))
+	return screen, nil
+}
+
+// pictureScreen shows the image file, converted to fit the board.
+func pictureScreen(board *emulator.Board, path string, dither bool) (*ledboard.Screen, error) {
+	img, err := graphics.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	picture, err := graphics.Convert(img, graphics.Options{Width: board.Width, Height: board.Height, Dither: dither})
+	if err != nil {
+		return nil, err
+	}
+	board.Pictures["1"] = picture
+
+	screen := ledboard.NewScreen()
+	screen.AddFrame().Picture("1")
+	return screen, nil
+}
+
+func playANSI(steps []emulator.Step) error {
+	for i, step := range steps {
+		if i > 0 {
+			// Move the cursor back up to draw over the previous image.
+			fmt.Printf("\x1b[%dA", (step.Matrix.Height+1)/2)
+		}
+		if err := emulator.WriteANSI(os.Stdout, step.Matrix); err != nil {
+			return err
+		}
+		time.Sleep(step.Delay)
+	}
+	return nil
+}
+
+func writeFile(path string, write func(f *os.File) error) error {
+	if path == "" {
+		return fmt.Errorf("missing output file")
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+)
+
+func main() {
+	signSpec := flag.String("sign", "127.0.0.1", "sign to send to, as in LEDBOARD_SIGNS, e.g. 127.0.0.1 or serial:///dev/ttyUSB0")
+	speed := flag.Float64("speed", 1, "replay speed, 2 replays twice as fast")
+	from := flag.String("from", "", "only replay the datagrams sent to this sign of the capture")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] capture.jsonl\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 || *speed <= 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	if err := replay(ctx, flag.Arg(0), *signSpec, *from, *speed); err != nil {
+		slog.Error("replay failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// replay sends the datagrams of the capture to the sign, keeping the time
+// between them divided by speed.
+func replay(ctx context.Context, path, signSpec, from string, speed float64) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	records, err := ledboard.ReadCapture(file)
+	_ = file.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read capture %s: %w", path, err)
+	}
+
+	sign, err := ledboard.ParseSign(signSpec)
+	if err != nil {
+		return err
+	}
+	transport, err := ledboard.Dial(sign.URL)
+	if err != nil {
+		return err
+	}
+	defer transport.Close()
+
+	// The sign's answers aren't needed, but stream transports must be read.
+	go func() {
+		for {
+			if _, err := transport.Receive(); errors.Is(err, net.ErrClosed) {
+				return
+			}
+		}
+	}()
+
+	var previous time.Time
+	start := time.Now()
+	sent := 0
+	for _, rec := range records {
+		if from != "" && rec.Sign != from {
+			continue
+		}
+		if !previous.IsZero() {
+			timer := time.NewTimer(time.Duration(float64(rec.Time.Sub(previous)) / speed))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil
+			case <-timer.C:
+			}
+		}
+		previous = rec.Time
+
+		args := []any{"recorded", rec.Time.Format(time.DateTime), "sign", rec.Sign}
+		if rec.Trigger != nil {
+			args = append(args, "topic", rec.Trigger.Topic, "payload", rec.Trigger.Payload)
+		}
+		if packet, err := ledboard.Decode(rec.Datagram); err == nil {
+			args = append(args, "packet", strings.TrimSpace(packet.String()))
+		}
+		slog.Info("replaying datagram", args...)
+
+		if err := transport.Send([]byte(rec.Datagram)); err != nil {
+			slog.Error("failed to send datagram", "error", err)
+		}
+		sent++
+	}
+	slog.Info("replay finished", "datagrams", sent, "duration", time.Since(start).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/simulator"
+)
+
+func main() {
+	udpAddr := flag.String("udp", ":9520", "address to receive datagrams on")
+	tcpAddr := flag.String("tcp", "", "address to accept packet streams on, like a TCP to serial converter, e.g. :10001")
+	pty := flag.Bool("pty", false, "serve a pseudo terminal as serial line, its path is logged")
+	echoAddr := flag.String("echo", ":7", "address of the tcp echo service used by the ping probe, empty to disable")
+	httpAddr := flag.String("http", "", "address to serve the web view on, e.g. localhost:8080")
+	terminal := flag.Bool("terminal", true, "draw the sign in the terminal")
+	width := flag.Int("width", 160, "board width in pixels")
+	height := flag.Int("height", 16, "board height in pixels")
+	scale := flag.Int("scale", 6, "image pixels per LED in the web view")
+	signType := flag.String("type", "Z", "sign type code")
+	address := flag.String("address", "01", "sign address, packets to 00 are accepted as well")
+	debug := flag.Bool("debug", false, "log every received packet")
+	flag.Parse()
+
+	logLevel := slog.LevelInfo
+	if *debug {
+		logLevel = slog.LevelDebug
+	}
+	// The terminal view owns stdout, so logs go to stderr.
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel})))
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	sign := simulator.NewSign(*width, *height)
+	sign.Type, sign.Address = *signType, *address
+	errs := make(chan error, 6)
+
+	go func() { errs <- sign.ListenUDP(ctx, *udpAddr) }()
+	if *tcpAddr != "" {
+		go func() { errs <- sign.ListenTCP(ctx, *tcpAddr) }()
+	}
+	if *pty {
+		go func() { errs <- sign.ListenPTY(ctx) }()
+	}
+	if *echoAddr != "" {
+		go func() { errs <- sign.ListenEcho(ctx, *echoAddr) }()
+	}
+	if *httpAddr != "" {
+		server := &http.Server{Addr: *httpAddr, Handler: sign.Handler(*scale, 200*time.Millisecond)}
+		go func() {
+			<-ctx.Done()
+			_ = server.Close()
+		}()
+		go func() {
+			slog.Info("serving web view", "address", *httpAddr)
+			if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				errs <- err
+			}
+		}()
+	}
+	if *terminal {
+		go func() { errs <- sign.RunTerminal(ctx, os.Stdout, 100*time.Millisecond) }()
+	}
+
+	select {
+	case <-ctx.Done():
+	case err := <-errs:
+		if err != nil {
+			slog.Error("simulator failed", "error", err)
+			os.Exit(1)
+		}
+	}
+}
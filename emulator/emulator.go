@@ -0,0 +1,544 @@
+package emulator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+	"github.com/b4ckspace/ledboard-v2/pixelfont"
+)
+
+const (
+	// defaultHold is how long a frame without any pause is shown.
+	defaultHold = 2 * time.Second
+
+	// flashPeriod is the duration of one on/off cycle of flashing text.
+	flashPeriod = time.Second
+
+	// maskSteps is the number of animation steps of patterns that are
+	// approximated by revealing pixels in a certain order.
+	maskSteps = 40
+)
+
+// stepDurations holds the time a single animation step takes at each speed.
+var stepDurations = map[ledboard.Speed]time.Duration{
+	ledboard.SpeedVeryFast:   5 * time.Millisecond,
+	ledboard.SpeedFast:       10 * time.Millisecond,
+	ledboard.SpeedMediumFast: 15 * time.Millisecond,
+	ledboard.SpeedMedium:     20 * time.Millisecond,
+	ledboard.SpeedMediumSlow: 30 * time.Millisecond,
+	ledboard.SpeedSlow:       40 * time.Millisecond,
+	ledboard.SpeedVerySlow:   60 * time.Millisecond,
+}
+
+// Board emulates a sign with the given pixel geometry.
+type Board struct {
+	Width  int
+	Height int
+
+	// Fonts holds the fonts uploaded into custom font slots. Slots without
+	// a font are drawn with the default font.
+	Fonts map[ledboard.Font]*ledboard.CustomFont
+
+	// Pictures holds the picture files by label. Missing pictures are not drawn.
+	Pictures map[string]*ledboard.Picture
+
+	// Strings holds the values of the string files by label. Missing strings
+	// are drawn empty.
+	Strings map[string]string
+
+	// Calibration scales the modeled duration of patterns to what was
+	// measured on the sign, e.g. 1.5 for a pattern taking half as long
+	// again. Patterns missing are not scaled.
+	Calibration map[ledboard.Pattern]float64
+}
+
+// NewBoard creates a new emulated board.
+func NewBoard(width, height int) *Board {
+	return &Board{
+		Width:    width,
+		Height:   height,
+		Fonts:    make(map[ledboard.Font]*ledboard.CustomFont),
+		Pictures: make(map[string]*ledboard.Picture),
+		Strings:  make(map[string]string),
+	}
+}
+
+// Step is a single rendered image and how long it stays on the board.
+type Step struct {
+	Matrix *Matrix
+	Delay  time.Duration
+}
+
+// RenderOptions controls how a screen is rendered into steps.
+type RenderOptions struct {
+	// Clock is the sign's time when the screen starts.
+	Clock time.Time
+	// Tick is the sampling interval of animations.
+	Tick time.Duration
+	// MaxHold caps how long a frame is held, so endless pauses stay short.
+	MaxHold time.Duration
+}
+
+// DecodeScreen extracts the screen of a write text datagram.
+func DecodeScreen(datagram string) (*ledboard.Screen, error) {
+	packet, err := ledboard.Decode(datagram)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode datagram: %w", err)
+	}
+	if packet.Screen == nil {
+		return nil, fmt.Errorf("datagram with command %q carries no screen", packet.Command)
+	}
+	return packet.Screen, nil
+}
+
+// Render plays the screen once and samples it into a sequence of steps.
+// Consecutive identical images are merged into a single step.
+func (b *Board) Render(screen *ledboard.Screen, opts RenderOptions) []Step {
+	if opts.Tick <= 0 {
+		opts.Tick = 50 * time.Millisecond
+	}
+	if opts.MaxHold <= 0 {
+		opts.MaxHold = 5 * time.Second
+	}
+
+	var steps []Step
+	add := func(m *Matrix, delay time.Duration) {
+		if last := len(steps) - 1; last >= 0 && steps[last].Matrix.Equal(m) {
+			steps[last].Delay += delay
+			return
+		}
+		steps = append(steps, Step{m, delay})
+	}
+
+	var offset time.Duration
+	for _, ph := range b.timeline(screen, opts.Clock) {
+		hold := min(ph.hold, opts.MaxHold)
+		for t := time.Duration(0); t < ph.in+hold+ph.out; t += opts.Tick {
+			at := t
+			if t >= ph.in+hold {
+				// Skip the part of the hold that was cut off.
+				at += ph.hold - hold
+			}
+			clock := opts.Clock.Add(offset + at)
+			add(b.drawPhase(screen, ph, at, clock), opts.Tick)
+		}
+		offset += ph.in + ph.hold + ph.out
+	}
+	return steps
+}
+
+// Snapshot returns what the board shows elapsed time after the screen was
+// received, while the sign's clock reads clock. Screens loop endlessly.
+func (b *Board) Snapshot(screen *ledboard.Screen, elapsed time.Duration, clock time.Time) *Matrix {
+	phases := b.timeline(screen, clock)
+	var total time.Duration
+	for _, ph := range phases {
+		total += ph.in + ph.hold + ph.out
+	}
+	if total == 0 {
+		return NewMatrix(b.Width, b.Height)
+	}
+
+	elapsed %= total
+	for _, ph := range phases {
+		if length := ph.in + ph.hold + ph.out; elapsed >= length {
+			elapsed -= length
+			continue
+		}
+		return b.drawPhase(screen, ph, elapsed, clock)
+	}
+	return NewMatrix(b.Width, b.Height)
+}
+
+// Duration returns how long the sign takes to play the screen once, while
+// its clock reads clock.
+func (b *Board) Duration(screen *ledboard.Screen, clock time.Time) time.Duration {
+	var total time.Duration
+	for _, ph := range b.timeline(screen, clock) {
+		total += ph.in + ph.hold + ph.out
+	}
+	return total
+}
+
+// phase is the timing of a single frame: animating in, holding, animating out.
+type phase struct {
+	frame int
+	start state
+	in    time.Duration
+	hold  time.Duration
+	out   time.Duration
+}
+
+func (b *Board) timeline(screen *ledboard.Screen, clock time.Time) []phase {
+	var phases []phase
+	st := defaultState()
+	for i, frame := range screen.Frames {
+		pg, next := b.layout(frame, st, clock)
+		phases = append(phases, phase{
+			frame: i,
+			start: st,
+			in:    b.patternDuration(pg.patternIn, pg.speed, pg.width()),
+			hold:  pg.hold,
+			out:   b.patternDuration(pg.patternOut, pg.speed, pg.width()),
+		})
+		st = next
+	}
+	return phases
+}
+
+func (b *Board) drawPhase(screen *ledboard.Screen, ph phase, at time.Duration, clock time.Time) *Matrix {
+	pg, _ := b.layout(screen.Frames[ph.frame], ph.start, clock)
+	m := b.draw(pg, at)
+
+	switch {
+	case at < ph.in:
+		return b.animate(m, pg.patternIn, float64(at)/float64(ph.in), true)
+	case at >= ph.in+ph.hold:
+		return b.animate(m, pg.patternOut, float64(at-ph.in-ph.hold)/float64(ph.out), false)
+	}
+	return m
+}
+
+// state holds the display attributes that carry over from segment to segment.
+type state struct {
+	font       ledboard.Font
+	color      ledboard.FontColor
+	background ledboard.BackgroundColor
+	flash      bool
+	horizontal ledboard.HorizontalAlign
+	vertical   ledboard.VerticalAlign
+	patternIn  ledboard.Pattern
+	patternOut ledboard.Pattern
+	speed      ledboard.Speed
+}
+
+func defaultState() state {
+	return state{
+		font:       defaultFont,
+		color:      ledboard.FontColorRed,
+		background: ledboard.BackgroundColorBlack,
+		horizontal: ledboard.AlignHorizontalCenter,
+		vertical:   ledboard.AlignVerticalCenter,
+		patternIn:  ledboard.PatternJumpOut,
+		patternOut: ledboard.PatternJumpOut,
+		speed:      ledboard.SpeedMedium,
+	}
+}
+
+// cell is a single character placed on a page.
+type cell struct {
+	char    byte
+	index   int
+	size    fontSize
+	glyph   *pixelfont.Glyph
+	picture *ledboard.Picture
+	color   ledboard.FontColor
+	flash   bool
+	half    bool
+}
+
+func (c cell) width() int {
+	if c.half {
+		return c.size.width / 2
+	}
+	return c.size.width
+}
+
+type line struct {
+	cells []cell
+}
+
+func (l line) width() int {
+	w := 0
+	for _, c := range l.cells {
+		w += c.width()
+	}
+	return w
+}
+
+func (l line) height() int {
+	h := 0
+	for _, c := range l.cells {
+		h = max(h, c.size.height)
+	}
+	return h
+}
+
+// page is a frame laid out into lines of characters.
+type page struct {
+	lines      []line
+	background ledboard.BackgroundColor
+	horizontal ledboard.HorizontalAlign
+	vertical   ledboard.VerticalAlign
+	patternIn  ledboard.Pattern
+	patternOut ledboard.Pattern
+	speed      ledboard.Speed
+	hold       time.Duration
+}
+
+func (p *page) width() int {
+	w := 0
+	for _, l := range p.lines {
+		w = max(w, l.width())
+	}
+	return w
+}
+
+// layout applies the segments of a frame to the given state and places the
+// characters, expanding specials with the clock.
+func (b *Board) layout(frame *ledboard.Frame, st state, clock time.Time) (*page, state) {
+	pg := &page{lines: []line{{}}}
+	index := 0
+	paused := false
+
+	add := func(text string, half bool) {
+		current := &pg.lines[len(pg.lines)-1]
+		for i := 0; i < len(text); i++ {
+			c := cell{
+				char:  text[i],
+				index: index,
+				size:  sizeOf(st.font),
+				color: st.color,
+				flash: st.flash,
+				half:  half,
+			}
+			if custom, ok := b.Fonts[st.font]; ok {
+				c.size = fontSize{height: custom.Height, width: c.size.width}
+				if glyph, ok := custom.Glyphs[c.char]; ok {
+					c.size.width = glyph.Width
+					c.glyph = &glyph
+				}
+			}
+			current.cells = append(current.cells, c)
+			index++
+		}
+	}
+
+	for _, segment := range frame.Segments {
+		switch s := segment.(type) {
+		case ledboard.TextSegment:
+			add(s.Text, false)
+		case ledboard.HalfSpaceSegment:
+			add(" ", true)
+		case ledboard.SpecialSegment:
+			add(formatSpecial(s.Special, clock), false)
+		case ledboard.VariableSegment:
+			add(b.Strings[s.Label], false)
+		case ledboard.PictureSegment:
+			if picture, ok := b.Pictures[s.Label]; ok {
+				current := &pg.lines[len(pg.lines)-1]
+				current.cells = append(current.cells, cell{
+					index:   index,
+					size:    fontSize{height: picture.Height, width: picture.Width},
+					picture: picture,
+				})
+				index++
+			}
+		case ledboard.LineFeedSegment:
+			pg.lines = append(pg.lines, line{})
+		case ledboard.FontSegment:
+			st.font = s.Font
+		case ledboard.ColorSegment:
+			st.color = s.Color
+		case ledboard.BackgroundSegment:
+			st.background = s.Color
+		case ledboard.FlashSegment:
+			st.flash = s.On
+		case ledboard.HorizontalAlignSegment:
+			st.horizontal = s.Align
+		case ledboard.VerticalAlignSegment:
+			st.vertical = s.Align
+		case ledboard.PatternInSegment:
+			st.patternIn = s.Pattern
+		case ledboard.PatternOutSegment:
+			st.patternOut = s.Pattern
+		case ledboard.SpeedSegment:
+			st.speed = s.Speed
+		case ledboard.PauseSegment:
+			paused = true
+			if s.Milliseconds {
+				pg.hold += time.Duration(s.Value) * time.Millisecond
+			} else {
+				pg.hold += time.Duration(s.Value) * time.Second
+			}
+		}
+	}
+	if !paused {
+		pg.hold = defaultHold
+	}
+
+	pg.background = st.background
+	pg.horizontal = st.horizontal
+	pg.vertical = st.vertical
+	pg.patternIn = st.patternIn
+	pg.patternOut = st.patternOut
+	pg.speed = st.speed
+	return pg, st
+}
+
+// formatSpecial renders the value the sign would insert for a special.
+func formatSpecial(special ledboard.Special, clock time.Time) string {
+	switch special {
+	case ledboard.SpecialMMDDYYSLA:
+		return clock.Format("01/02/06")
+	case ledboard.SpecialDDMMYYSLA:
+		return clock.Format("02/01/06")
+	case ledboard.SpecialMMDDYYDSH:
+		return clock.Format("01-02-06")
+	case ledboard.SpecialDDMMYYDSH:
+		return clock.Format("02-01-06")
+	case ledboard.SpecialMMDDYYYYDOT:
+		return clock.Format("01.02.2006")
+	case ledboard.SpecialYY:
+		return clock.Format("06")
+	case ledboard.SpecialYYYY:
+		return clock.Format("2006")
+	case ledboard.SpecialMM:
+		return clock.Format("01")
+	case ledboard.SpecialMMM:
+		return clock.Format("Jan")
+	case ledboard.SpecialDD:
+		return clock.Format("02")
+	case ledboard.SpecialDDOfWeek:
+		return fmt.Sprintf("%d", ledboard.SignWeekday(clock.Weekday()))
+	case ledboard.SpecialDDDOfWeek:
+		return clock.Format("Mon")
+	case ledboard.SpecialHH:
+		return clock.Format("15")
+	case ledboard.SpecialMIN:
+		return clock.Format("04")
+	case ledboard.SpecialSEC:
+		return clock.Format("05")
+	case ledboard.SpecialHHMin24:
+		return clock.Format("15:04")
+	case ledboard.SpecialHHMin12:
+		return clock.Format("03:04PM")
+	}
+	return ""
+}
+
+// draw renders the page at time at into the frame's hold.
+func (b *Board) draw(pg *page, at time.Duration) *Matrix {
+	m := NewMatrix(b.Width, b.Height)
+	m.Fill(backgroundColor(pg.background))
+
+	total := 0
+	for _, l := range pg.lines {
+		total += l.height()
+	}
+	y := 0
+	switch pg.vertical {
+	case ledboard.AlignVerticalCenter:
+		y = (b.Height - total) / 2
+	case ledboard.AlignVerticalBottom:
+		y = b.Height - total
+	}
+
+	flashVisible := at%flashPeriod < flashPeriod/2
+	for _, l := range pg.lines {
+		height := l.height()
+		x := 0
+		if width := l.width(); width < b.Width {
+			switch pg.horizontal {
+			case ledboard.AlignHorizontalCenter:
+				x = (b.Width - width) / 2
+			case ledboard.AlignHorizontalRight:
+				x = b.Width - width
+			}
+		}
+
+		for _, c := range l.cells {
+			if !c.flash || flashVisible {
+				// Characters of smaller fonts sit on the line's baseline.
+				b.drawCell(m, c, x, y+height-c.size.height, at)
+			}
+			x += c.width()
+		}
+		y += height
+	}
+	return m
+}
+
+func (b *Board) drawCell(m *Matrix, c cell, left, top int, at time.Duration) {
+	if c.half {
+		return
+	}
+	if c.picture != nil {
+		// Pixels that are off show the background.
+		for dy := 0; dy < c.picture.Height; dy++ {
+			for dx := 0; dx < c.picture.Width; dx++ {
+				if color := c.picture.At(dx, dy); color != ledboard.PixelOff {
+					m.Set(left+dx, top+dy, pixelColor(color))
+				}
+			}
+		}
+		return
+	}
+	if c.glyph != nil {
+		for dy := 0; dy < c.size.height; dy++ {
+			for dx := 0; dx < c.glyph.Width; dx++ {
+				if c.glyph.Pixel(dx, dy) {
+					x, y := left+dx, top+dy
+					m.Set(x, y, fontColor(c.color, c.index, x, y, at))
+				}
+			}
+		}
+		return
+	}
+	for dy := 0; dy < c.size.height*8/7; dy++ {
+		for dx := 0; dx < c.size.width; dx++ {
+			if glyphPixel(c.char, c.size, dx, dy) {
+				x, y := left+dx, top+dy
+				m.Set(x, y, fontColor(c.color, c.index, x, y, at))
+			}
+		}
+	}
+}
+
+// ygr is the color cycle of the multi color font modes.
+var ygr = [3]Color{Yellow, Green, Red}
+
+func fontColor(color ledboard.FontColor, index, x, y int, at time.Duration) Color {
+	switch color {
+	case ledboard.FontColorBlack:
+		return Off
+	case ledboard.FontColorGreen:
+		return Green
+	case ledboard.FontColorYellow:
+		return Yellow
+	case ledboard.FontColorYGRCharacter:
+		return ygr[index%3]
+	case ledboard.FontColorYGRHorizontal:
+		return ygr[(y/2)%3]
+	case ledboard.FontColorYGRWave:
+		return ygr[((x+y)/3+int(at/(100*time.Millisecond)))%3]
+	case ledboard.FontColorYGRDiagonal:
+		return ygr[((x+y)/3)%3]
+	}
+	return Red
+}
+
+func pixelColor(color ledboard.PixelColor) Color {
+	switch color {
+	case ledboard.PixelRed:
+		return Red
+	case ledboard.PixelGreen:
+		return Green
+	case ledboard.PixelAmber:
+		return Yellow
+	}
+	return Off
+}
+
+func backgroundColor(color ledboard.BackgroundColor) Color {
+	switch color {
+	case ledboard.BackgroundColorRed:
+		return Red
+	case ledboard.BackgroundColorGreen:
+		return Green
+	case ledboard.BackgroundColorYellow:
+		return Yellow
+	}
+	return Off
+}
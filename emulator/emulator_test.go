@@ -0,0 +1,141 @@
+package emulator
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+)
+
+// frames builds a screen of a frame per function.
+func frames(build ...func(f *ledboard.Frame)) *ledboard.Screen {
+	screen := ledboard.NewScreen()
+	for _, b := range build {
+		b(screen.AddFrame())
+	}
+	return screen
+}
+
+func TestDuration(t *testing.T) {
+	calibrated := map[ledboard.Pattern]float64{ledboard.PatternMoveLeft: 1.5, ledboard.PatternJumpOut: 2}
+	tests := []struct {
+		name        string
+		screen      *ledboard.Screen
+		calibration map[ledboard.Pattern]float64
+		want        time.Duration
+	}{
+		{
+			name:   "empty",
+			screen: ledboard.NewScreen(),
+		},
+		{
+			name:   "without pause",
+			screen: frames(func(f *ledboard.Frame) { f.Text("a") }),
+			want:   defaultHold,
+		},
+		{
+			name:   "pause",
+			screen: frames(func(f *ledboard.Frame) { f.Text("a").PauseSeconds(4) }),
+			want:   4 * time.Second,
+		},
+		{
+			name:   "pauses add up",
+			screen: frames(func(f *ledboard.Frame) { f.Text("a").PauseSeconds(1).Text("b").PauseMilliseconds(400) }),
+			want:   1400 * time.Millisecond,
+		},
+		{
+			name:   "zero pause",
+			screen: frames(func(f *ledboard.Frame) { f.Text("a").PauseSeconds(0) }),
+		},
+		{
+			name: "frames",
+			screen: frames(func(f *ledboard.Frame) { f.Text("a").PauseSeconds(1) },
+				func(f *ledboard.Frame) { f.Text("b") }),
+			want: time.Second + defaultHold,
+		},
+		{
+			name: "moving across the board",
+			screen: frames(func(f *ledboard.Frame) {
+				f.PatternIn(ledboard.PatternMoveLeft).Text("a").PauseSeconds(1)
+			}),
+			// A step of the medium speed per column.
+			want: 160*20*time.Millisecond + time.Second,
+		},
+		{
+			name: "moving text wider than the board",
+			screen: frames(func(f *ledboard.Frame) {
+				f.Font(ledboard.FontNormal7x6).PatternIn(ledboard.PatternMoveLeft).Text(strings.Repeat("a", 40)).PauseSeconds(1)
+			}),
+			want: 40*6*20*time.Millisecond + time.Second,
+		},
+		{
+			name: "scrolling in and out",
+			screen: frames(func(f *ledboard.Frame) {
+				f.PatternIn(ledboard.PatternScrollUp).PatternOut(ledboard.PatternScrollDown).Speed(ledboard.SpeedFast).
+					Text("a").PauseSeconds(1)
+			}),
+			// A step of the fast speed per row, each way.
+			want: 2*16*10*time.Millisecond + time.Second,
+		},
+		{
+			name: "wipe",
+			screen: frames(func(f *ledboard.Frame) {
+				f.PatternIn(ledboard.PatternRadarScan).Speed(ledboard.SpeedSlow).Text("a").PauseSeconds(1)
+			}),
+			want: maskSteps*40*time.Millisecond + time.Second,
+		},
+		{
+			name: "style carries over",
+			screen: frames(func(f *ledboard.Frame) {
+				f.PatternIn(ledboard.PatternRadarScan).Text("a").PauseSeconds(1)
+			}, func(f *ledboard.Frame) {
+				f.Text("b").PauseSeconds(1)
+			}),
+			want: 2 * (maskSteps*20*time.Millisecond + time.Second),
+		},
+		{
+			name: "calibrated",
+			screen: frames(func(f *ledboard.Frame) {
+				f.PatternIn(ledboard.PatternMoveLeft).PatternOut(ledboard.PatternScrollUp).Text("a").PauseSeconds(1)
+			}),
+			calibration: calibrated,
+			want:        160*30*time.Millisecond + 16*20*time.Millisecond + time.Second,
+		},
+		{
+			name:        "jumping out takes no time",
+			screen:      frames(func(f *ledboard.Frame) { f.Text("a").PauseSeconds(1) }),
+			calibration: calibrated,
+			want:        time.Second,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := NewBoard(160, 16)
+			b.Calibration = test.calibration
+			if got := b.Duration(test.screen, time.Now()); got != test.want {
+				t.Errorf("Duration = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+// TestDurationMatchesRender checks that the duration is what playing the
+// screen takes.
+func TestDurationMatchesRender(t *testing.T) {
+	b := NewBoard(160, 16)
+	screen := frames(func(f *ledboard.Frame) {
+		f.PatternIn(ledboard.PatternScrollUp).PatternOut(ledboard.PatternPeelOffR).Text("a").PauseSeconds(1)
+	}, func(f *ledboard.Frame) {
+		f.PatternIn(ledboard.PatternMoveLeft).Text("b").PauseMilliseconds(500)
+	})
+	steps := b.Render(screen, RenderOptions{Tick: 10 * time.Millisecond})
+	var total time.Duration
+	for _, step := range steps {
+		total += step.Delay
+	}
+	if want := b.Duration(screen, time.Now()); total != want {
+		t.Errorf("rendered %s, Duration = %s", total, want)
+	}
+}
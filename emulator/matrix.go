@@ -0,0 +1,64 @@
+package emulator
+
+import "slices"
+
+// Color is the state of a single tri-color LED.
+type Color uint8
+
+const (
+	Off Color = iota
+	Red
+	Green
+	Yellow
+)
+
+// Matrix holds the color of every LED of the board.
+type Matrix struct {
+	Width  int
+	Height int
+	Pixels []Color
+}
+
+// NewMatrix creates a matrix with all LEDs turned off.
+func NewMatrix(width, height int) *Matrix {
+	return &Matrix{width, height, make([]Color, width*height)}
+}
+
+// At returns the color at x, y, or Off outside of the matrix.
+func (m *Matrix) At(x, y int) Color {
+	if x < 0 || y < 0 || x >= m.Width || y >= m.Height {
+		return Off
+	}
+	return m.Pixels[y*m.Width+x]
+}
+
+// Set sets the color at x, y. Coordinates outside of the matrix are ignored.
+func (m *Matrix) Set(x, y int, c Color) {
+	if x < 0 || y < 0 || x >= m.Width || y >= m.Height {
+		return
+	}
+	m.Pixels[y*m.Width+x] = c
+}
+
+// Fill sets every LED to the given color.
+func (m *Matrix) Fill(c Color) {
+	for i := range m.Pixels {
+		m.Pixels[i] = c
+	}
+}
+
+// Shift returns a copy of the matrix moved by dx, dy.
+func (m *Matrix) Shift(dx, dy int) *Matrix {
+	out := NewMatrix(m.Width, m.Height)
+	for y := 0; y < m.Height; y++ {
+		for x := 0; x < m.Width; x++ {
+			out.Set(x+dx, y+dy, m.At(x, y))
+		}
+	}
+	return out
+}
+
+// Equal reports whether both matrices show the same image.
+func (m *Matrix) Equal(other *Matrix) bool {
+	return m.Width == other.Width && m.Height == other.Height && slices.Equal(m.Pixels, other.Pixels)
+}
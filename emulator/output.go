@@ -0,0 +1,98 @@
+package emulator
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// palette maps the LED colors to RGB. Index 0 is the board between the LEDs.
+var palette = color.Palette{
+	color.RGBA{0x10, 0x10, 0x10, 0xff},
+	color.RGBA{0x30, 0x24, 0x24, 0xff},
+	color.RGBA{0xff, 0x20, 0x10, 0xff},
+	color.RGBA{0x20, 0xe0, 0x30, 0xff},
+	color.RGBA{0xff, 0xc0, 0x10, 0xff},
+}
+
+// Image renders the matrix with every LED drawn as a dot of scale pixels.
+func (m *Matrix) Image(scale int) *image.Paletted {
+	scale = max(scale, 1)
+	img := image.NewPaletted(image.Rect(0, 0, m.Width*scale, m.Height*scale), palette)
+
+	// Leave a gap between the LEDs once they are large enough.
+	dot := scale
+	if scale >= 3 {
+		dot = scale - 1
+	}
+
+	for y := 0; y < m.Height; y++ {
+		for x := 0; x < m.Width; x++ {
+			index := uint8(m.At(x, y)) + 1
+			for dy := 0; dy < dot; dy++ {
+				for dx := 0; dx < dot; dx++ {
+					img.SetColorIndex(x*scale+dx, y*scale+dy, index)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// WritePNG writes the matrix as a PNG image.
+func WritePNG(w io.Writer, m *Matrix, scale int) error {
+	return png.Encode(w, m.Image(scale))
+}
+
+// WritePNGFrames writes every step as a numbered PNG file into dir.
+func WritePNGFrames(dir string, steps []Step, scale int) error {
+	for i, step := range steps {
+		f, err := os.Create(filepath.Join(dir, fmt.Sprintf("frame-%04d.png", i)))
+		if err != nil {
+			return fmt.Errorf("failed to create png frame: %w", err)
+		}
+		err = WritePNG(f, step.Matrix, scale)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write png frame: %w", err)
+		}
+	}
+	return nil
+}
+
+// WriteGIF writes the steps as a looping animated GIF.
+func WriteGIF(w io.Writer, steps []Step, scale int) error {
+	anim := &gif.GIF{}
+	for _, step := range steps {
+		anim.Image = append(anim.Image, step.Matrix.Image(scale))
+		anim.Delay = append(anim.Delay, max(int(step.Delay.Milliseconds()/10), 1))
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+// WriteANSI writes the matrix to a truecolor terminal. Every character cell
+// shows two LEDs on top of each other.
+func WriteANSI(w io.Writer, m *Matrix) error {
+	bw := bufio.NewWriter(w)
+	for y := 0; y < m.Height; y += 2 {
+		for x := 0; x < m.Width; x++ {
+			top := palette[m.At(x, y)+1].(color.RGBA)
+			bottom := palette[0].(color.RGBA)
+			if y+1 < m.Height {
+				bottom = palette[m.At(x, y+1)+1].(color.RGBA)
+			}
+			fmt.Fprintf(bw, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀",
+				top.R, top.G, top.B, bottom.R, bottom.G, bottom.B)
+		}
+		bw.WriteString("\x1b[0m\n")
+	}
+	return bw.Flush()
+}
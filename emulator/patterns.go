@@ -0,0 +1,119 @@
+package emulator
+
+import (
+	"math"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+)
+
+// direction is the way a translating pattern moves the frame.
+type direction struct{ dx, dy int }
+
+var (
+	left  = direction{-1, 0}
+	right = direction{1, 0}
+	up    = direction{0, -1}
+	down  = direction{0, 1}
+)
+
+// translations are the patterns that move the whole frame across the board.
+var translations = map[ledboard.Pattern]direction{
+	ledboard.PatternMoveLeft:    left,
+	ledboard.PatternMoveRight:   right,
+	ledboard.PatternScrollLeft:  left,
+	ledboard.PatternScrollRight: right,
+	ledboard.PatternMoveUp:      up,
+	ledboard.PatternMoveDown:    down,
+	ledboard.PatternScrollUp:    up,
+	ledboard.PatternScrollDown:  down,
+}
+
+// patternDuration returns how long the pattern takes to animate a page of the
+// given width, calibrated.
+func (b *Board) patternDuration(pattern ledboard.Pattern, speed ledboard.Speed, width int) time.Duration {
+	step, ok := stepDurations[speed]
+	if !ok {
+		step = stepDurations[ledboard.SpeedMedium]
+	}
+	if factor, ok := b.Calibration[pattern]; ok {
+		step = time.Duration(float64(step) * factor)
+	}
+
+	if pattern == ledboard.PatternJumpOut {
+		return 0
+	}
+	if dir, ok := translations[pattern]; ok {
+		if dir.dx != 0 {
+			return time.Duration(max(b.Width, width)) * step
+		}
+		return time.Duration(b.Height) * step
+	}
+	return maskSteps * step
+}
+
+// animate applies the pattern to the fully drawn frame. Progress runs from 0
+// to 1; entering frames appear while leaving frames disappear.
+func (b *Board) animate(m *Matrix, pattern ledboard.Pattern, progress float64, entering bool) *Matrix {
+	if dir, ok := translations[pattern]; ok {
+		// Entering frames move from the far side to their resting position,
+		// leaving frames move from their resting position out of the board.
+		offset := progress
+		if entering {
+			offset = progress - 1
+		}
+		return m.Shift(int(offset*float64(dir.dx*b.Width)), int(offset*float64(dir.dy*b.Height)))
+	}
+
+	out := NewMatrix(b.Width, b.Height)
+	for y := 0; y < b.Height; y++ {
+		for x := 0; x < b.Width; x++ {
+			revealed := b.threshold(pattern, x, y) < progress
+			if revealed == entering {
+				out.Set(x, y, m.At(x, y))
+			}
+		}
+	}
+	return out
+}
+
+// threshold returns the point in the animation, between 0 and 1, at which
+// the pixel at x, y changes. It approximates the sign's wipe style patterns.
+func (b *Board) threshold(pattern ledboard.Pattern, x, y int) float64 {
+	w, h := float64(b.Width), float64(b.Height)
+	fx, fy := (float64(x)+0.5)/w, (float64(y)+0.5)/h
+	cx, cy := math.Abs(fx-0.5)*2, math.Abs(fy-0.5)*2
+	angle := (math.Atan2(float64(y)-h/2, float64(x)-w/2) + math.Pi) / (2 * math.Pi)
+
+	switch pattern {
+	case ledboard.PatternFoldLR, ledboard.PatternScrollLR, ledboard.PatternShuttleLR:
+		return 1 - cx
+	case ledboard.PatternFoldUD, ledboard.PatternScrollUD, ledboard.PatternShuttleUD:
+		return 1 - cy
+	case ledboard.PatternPeelOffL:
+		return 1 - fx
+	case ledboard.PatternPeelOffR:
+		return fx
+	case ledboard.PatternShutterUD:
+		return float64(y%4) / 4
+	case ledboard.PatternShutterLR:
+		return float64(x%8) / 8
+	case ledboard.PatternRadarScan, ledboard.PatternFanOut:
+		return angle
+	case ledboard.PatternFanIn:
+		return 1 - angle
+	case ledboard.PatternSpiralR:
+		return math.Mod(angle+max(cx, cy), 1)
+	case ledboard.PatternSpiralL:
+		return math.Mod(1-angle+max(cx, cy), 1)
+	case ledboard.PatternToFourCorners, ledboard.PatternToFourSides:
+		return max(cx, cy)
+	case ledboard.PatternFromFourCorners, ledboard.PatternFromFourSides, ledboard.PatternScrollOutFromFourBlocks:
+		return 1 - max(cx, cy)
+	}
+
+	// Random looking patterns like raindrops, mosaic or twinkling stars.
+	hash := uint32(x)*2654435761 ^ uint32(y)*2246822519
+	hash ^= hash >> 15
+	return float64(hash%1000) / 1000
+}
@@ -0,0 +1,161 @@
+// Package graphics converts images into pictures the LED board can show.
+package graphics
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+)
+
+// Options controls how an image is converted.
+type Options struct {
+	// Width and Height bound the picture. The image is scaled to fit while
+	// keeping its aspect ratio.
+	Width  int
+	Height int
+
+	// Dither spreads the quantization error to neighbouring pixels, which
+	// suits photos. Without it every pixel takes the nearest color, which
+	// keeps logos and icons crisp.
+	Dither bool
+}
+
+// rgb is a color with components from 0 to 1, alpha already applied.
+type rgb struct{ r, g, b float64 }
+
+// palette holds the colors the LEDs can show.
+var palette = []struct {
+	pixel ledboard.PixelColor
+	color rgb
+}{
+	{ledboard.PixelOff, rgb{0, 0, 0}},
+	{ledboard.PixelRed, rgb{1, 0, 0}},
+	{ledboard.PixelGreen, rgb{0, 1, 0}},
+	{ledboard.PixelAmber, rgb{1, 0.75, 0}},
+}
+
+// Load reads a PNG, GIF or JPEG image.
+func Load(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image: %w", err)
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image %s: %w", path, err)
+	}
+	return img, nil
+}
+
+// Convert scales the image to fit the options' bounds and reduces it to the
+// colors of the LED board. Transparent pixels are off.
+func Convert(img image.Image, opts Options) (*ledboard.Picture, error) {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return nil, fmt.Errorf("image is empty")
+	}
+	if opts.Width <= 0 || opts.Height <= 0 {
+		return nil, fmt.Errorf("invalid picture bounds %dx%d", opts.Width, opts.Height)
+	}
+
+	width, height := fit(bounds.Dx(), bounds.Dy(), opts.Width, opts.Height)
+	pixels := scale(img, width, height)
+
+	picture := ledboard.NewPicture(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			old := pixels[y*width+x]
+			index := nearest(old)
+			picture.Set(x, y, palette[index].pixel)
+			if !opts.Dither {
+				continue
+			}
+
+			// Floyd-Steinberg error diffusion.
+			c := palette[index].color
+			diff := rgb{old.r - c.r, old.g - c.g, old.b - c.b}
+			spread := func(dx, dy int, weight float64) {
+				x, y := x+dx, y+dy
+				if x < 0 || x >= width || y >= height {
+					return
+				}
+				p := &pixels[y*width+x]
+				p.r += diff.r * weight
+				p.g += diff.g * weight
+				p.b += diff.b * weight
+			}
+			spread(1, 0, 7.0/16)
+			spread(-1, 1, 3.0/16)
+			spread(0, 1, 5.0/16)
+			spread(1, 1, 1.0/16)
+		}
+	}
+	return picture, nil
+}
+
+// fit returns the largest size within the bounds keeping the aspect ratio of
+// the source, at least a single pixel.
+func fit(srcWidth, srcHeight, maxWidth, maxHeight int) (int, int) {
+	width, height := maxWidth, srcHeight*maxWidth/srcWidth
+	if height > maxHeight {
+		width, height = srcWidth*maxHeight/srcHeight, maxHeight
+	}
+	return max(width, 1), max(height, 1)
+}
+
+// scale resamples the image to the given size. Every target pixel averages
+// the source pixels it covers, which makes downscaling smooth; when scaling
+// up it takes the nearest source pixel.
+func scale(img image.Image, width, height int) []rgb {
+	bounds := img.Bounds()
+	pixels := make([]rgb, width*height)
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(bounds.Min.Y+(y+1)*bounds.Dy()/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(bounds.Min.X+(x+1)*bounds.Dx()/width, x0+1)
+
+			var sum rgb
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := toRGB(img.At(sx, sy))
+					sum.r += c.r
+					sum.g += c.g
+					sum.b += c.b
+				}
+			}
+			n := float64((x1 - x0) * (y1 - y0))
+			pixels[y*width+x] = rgb{sum.r / n, sum.g / n, sum.b / n}
+		}
+	}
+	return pixels
+}
+
+// toRGB converts a color, blending transparent pixels onto black.
+func toRGB(c color.Color) rgb {
+	r, g, b, _ := c.RGBA()
+	return rgb{float64(r) / 0xffff, float64(g) / 0xffff, float64(b) / 0xffff}
+}
+
+// nearest returns the index of the palette color closest to c.
+func nearest(c rgb) int {
+	best, bestDistance := 0, -1.0
+	for i, p := range palette {
+		dr, dg, db := c.r-p.color.r, c.g-p.color.g, c.b-p.color.b
+		// Weighted like the eye's sensitivity, green counting most.
+		distance := 0.3*dr*dr + 0.59*dg*dg + 0.11*db*db
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = i, distance
+		}
+	}
+	return best
+}
@@ -1,92 +1,463 @@
 package ledboard
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
-
-	"github.com/b4ckspace/ledboard-v2/utils"
 )
 
-// Client implements the LEDBoardClient interface.
+// Client sends to one or more signs. Every call fans out to all of them.
 type Client struct {
-	conn *net.UDPConn
+	targets []*target
+	groups  map[string][]string
+
+	// trigger is recorded with every datagram this client sends.
+	trigger *Trigger
 }
 
-// NewClient creates a new LED board client instance.
-func NewClient(host string, port int) (*Client, error) {
-	addr, err := net.ResolveUDPAddr("udp4", fmt.Sprintf("%s:%d", host, port))
-	if err != nil {
-		return nil, fmt.Errorf("failed to resolve UDP address: %w", err)
+// target is a sign together with its transport.
+type target struct {
+	sign      Sign
+	transport Transport
+
+	// queue holds the datagrams the writer sends one after another.
+	queue *sendQueue
+	// recorder captures the datagrams sent, if set.
+	recorder atomic.Pointer[Recorder]
+
+	statsMu sync.Mutex
+	stats   SignStats
+
+	// exchange serializes requests waiting for an answer.
+	exchange  sync.Mutex
+	responses chan string
+}
+
+var (
+	// ErrQueueFull is returned when a sign's send queue has no room left.
+	ErrQueueFull = errors.New("send queue is full")
+	// ErrClosed is returned when sending through a closed client.
+	ErrClosed = errors.New("client is closed")
+	// ErrPacketTooLarge is returned when a packet exceeds a sign's MaxPacket,
+	// SplitScreen cuts screens that are too long into several files.
+	ErrPacketTooLarge = errors.New("packet is too large")
+)
+
+// NewClient creates a new LED board client instance for the given signs.
+// Groups map a group name to the names of the signs it contains. Every sign
+// gets a writer sending its queued packets as the options allow.
+func NewClient(signs []Sign, groups map[string][]string, queue QueueOptions) (*Client, error) {
+	if len(signs) == 0 {
+		return nil, fmt.Errorf("no signs configured")
 	}
 
-	conn, err := net.DialUDP("udp4", nil, addr)
-	if err != nil {
-		return nil, fmt.Errorf("failed to dial UDP: %w", err)
+	c := &Client{groups: groups}
+	for _, sign := range signs {
+		transport, err := Dial(sign.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to sign %s: %w", sign.Name, err)
+		}
+		t := &target{sign: sign, transport: transport, queue: newSendQueue(queue.Size), responses: make(chan string, 8)}
+		go t.receive()
+		go t.write(queue.Gap)
+		c.targets = append(c.targets, t)
+	}
+
+	for group, members := range groups {
+		for _, member := range members {
+			if c.find(member) == nil {
+				return nil, fmt.Errorf("group %s: unknown sign %s", group, member)
+			}
+		}
+	}
+
+	slog.Info("LED Board Client initialized", "signs", len(c.targets))
+	return c, nil
+}
+
+// Group returns a client that only sends to the signs of the named group.
+// A sign name selects just that sign.
+func (c *Client) Group(name string) (*Client, error) {
+	members, ok := c.groups[name]
+	if !ok {
+		members = []string{name}
+	}
+
+	group := &Client{groups: c.groups, trigger: c.trigger}
+	for _, member := range members {
+		t := c.find(member)
+		if t == nil {
+			return nil, fmt.Errorf("unknown sign or group %s", name)
+		}
+		group.targets = append(group.targets, t)
+	}
+	return group, nil
+}
+
+// WithTrigger returns a client sending to the same signs, recording the
+// trigger with every datagram it sends.
+func (c *Client) WithTrigger(trigger Trigger) *Client {
+	return &Client{targets: c.targets, groups: c.groups, trigger: &trigger}
+}
+
+// Record captures every datagram sent to the client's signs from now on.
+func (c *Client) Record(recorder *Recorder) {
+	for _, t := range c.targets {
+		t.recorder.Store(recorder)
+	}
+}
+
+// Close drops the queued packets and closes the connections to all signs.
+func (c *Client) Close() {
+	for _, t := range c.targets {
+		t.queue.close()
+		_ = t.transport.Close()
+	}
+}
+
+// Stats returns what was sent to every sign of the client.
+func (c *Client) Stats() []SignStats {
+	stats := make([]SignStats, len(c.targets))
+	for i, t := range c.targets {
+		stats[i] = t.snapshot()
+	}
+	return stats
+}
+
+// Signs returns the signs this client sends to.
+func (c *Client) Signs() []Sign {
+	signs := make([]Sign, len(c.targets))
+	for i, t := range c.targets {
+		signs[i] = t.sign
 	}
-	slog.Info("LED Board Client initialized")
-	return &Client{conn}, nil
+	return signs
+}
+
+func (c *Client) find(name string) *target {
+	for _, t := range c.targets {
+		if t.sign.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// Send queues a raw datagram for every sign of the client.
+func (c *Client) Send(datagram string) error {
+	return c.enqueue("", func(*target) string { return datagram })
 }
 
-// Send sends a datagram to the LED board.
-func (c *Client) Send(datagram string) {
+func (t *target) send(datagram string) {
 	message := []byte(datagram)
 
-	// Debugging: Print the raw datagram being sent
-	slog.Debug("Sending to LED board (raw)", "datagram", fmt.Sprintf("%q", datagram))
+	// Debugging: Print the raw and the decoded datagram being sent
+	slog.Debug("Sending to LED board (raw)", "sign", t.sign.Name, "datagram", fmt.Sprintf("%q", datagram))
+	if slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		if packet, err := Decode(datagram); err != nil {
+			slog.Debug("Sending undecodable datagram to LED board", "sign", t.sign.Name, "error", err)
+		} else {
+			slog.Debug("Sending to LED board (decoded)\n" + packet.String())
+		}
+	}
+
+	if err := t.transport.Send(message); err != nil {
+		slog.Error("failed sending to LED board", "sign", t.sign.Name, "error", err)
+		t.failed(err)
+		return
+	}
+	t.succeeded(len(message))
+}
+
+// receive reads the answers of the sign until the transport is closed.
+func (t *target) receive() {
+	for {
+		answer, err := t.transport.Receive()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			// ICMP errors of earlier UDP writes show up here and broken
+			// connections are reopened, neither ends the transport.
+			slog.Debug("failed reading from LED board", "sign", t.sign.Name, "error", err)
+			t.failed(err)
+			continue
+		}
+
+		response := string(answer)
+		slog.Debug("Received from LED board (raw)", "sign", t.sign.Name, "response", fmt.Sprintf("%q", response))
+		select {
+		case t.responses <- response:
+		default:
+			slog.Debug("dropping unsolicited response of LED board", "sign", t.sign.Name)
+		}
+	}
+}
+
+// request sends the datagram and waits for the sign to answer, retrying as
+// the policy allows.
+func (t *target) request(ctx context.Context, datagram string, policy Policy, trigger *Trigger) (Result, error) {
+	t.exchange.Lock()
+	defer t.exchange.Unlock()
+
+	// Read requests are answered with a packet, a plain acknowledge still
+	// belongs to an earlier send that was answered late.
+	command := datagram[len(t.sign.header()):]
+	read := strings.HasPrefix(command, CommandReadText) || strings.HasPrefix(command, CommandReadString) ||
+		strings.HasPrefix(command, CommandReadFunction)
 
-	_, err := c.conn.Write(message)
+	result := Result{Sign: t.sign}
+	for result.Attempts <= policy.Retries {
+		result.Attempts++
+		status, packet, err := t.attempt(ctx, datagram, policy.Timeout, read, trigger)
+		if err != nil {
+			return result, err
+		}
+		if status == StatusTimeout {
+			slog.Debug("LED board did not answer", "sign", t.sign.Name, "attempt", result.Attempts)
+			continue
+		}
+		result.Status, result.Packet = status, packet
+		return result, nil
+	}
+
+	result.Status = StatusTimeout
+	return result, nil
+}
+
+// attempt sends the datagram once, after the datagrams queued before it,
+// and waits for the answer. The sign gets nothing else until it answered.
+func (t *target) attempt(ctx context.Context, datagram string, timeout time.Duration, read bool, trigger *Trigger) (Status, *Packet, error) {
+	answered := make(chan struct{})
+	defer close(answered)
+
+	item, err := t.enqueue("", datagram, trigger, answered)
 	if err != nil {
-		slog.Error("failed sending UDP message", "error", err)
+		return StatusTimeout, nil, err
 	}
+	select {
+	case <-ctx.Done():
+		return StatusTimeout, nil, ctx.Err()
+	case <-item.sent:
+	}
+	return t.await(ctx, timeout, read)
 }
 
-// SetDate sets the date on the LED board.
-func (c *Client) SetDate(date time.Time) {
+// discardResponses drops the answers received so far.
+func (t *target) discardResponses() {
+	for len(t.responses) > 0 {
+		<-t.responses
+	}
+}
+
+// await waits for a single answer of the sign, skipping answers that can't be
+// decoded and, when reading, plain acknowledges.
+func (t *target) await(ctx context.Context, timeout time.Duration, read bool) (Status, *Packet, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return StatusTimeout, nil, ctx.Err()
+		case <-timer.C:
+			return StatusTimeout, nil, nil
+		case response := <-t.responses:
+			status, packet, err := DecodeResponse(response)
+			if err != nil {
+				slog.Warn("invalid answer of LED board", "sign", t.sign.Name, "error", err)
+				continue
+			}
+			if read && status == StatusAcked && packet == nil {
+				continue
+			}
+			return status, packet, nil
+		}
+	}
+}
+
+// requestAll runs the request against every sign concurrently. The datagram
+// is built per sign, as it contains the sign's address.
+func (c *Client) requestAll(ctx context.Context, build func(t *target) string, policy Policy) ([]Result, error) {
+	results := make([]Result, len(c.targets))
+	errs := make([]error, len(c.targets))
+
+	var wg sync.WaitGroup
+	for i, t := range c.targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = t.request(ctx, build(t), policy, c.trigger)
+		}()
+	}
+	wg.Wait()
+
+	return results, errors.Join(errs...)
+}
+
+// SetDateWait sets the date on the LED board and waits for it to be acknowledged.
+func (c *Client) SetDateWait(ctx context.Context, date time.Time, policy Policy) ([]Result, error) {
 	slog.Info("pushing datetime", "time", date)
-	var cmd string
-	cmd += "\x01Z00\x02E"
-	cmd += "B"
+	return c.requestAll(ctx, func(t *target) string {
+		return t.sign.packet(DateFunction(date))
+	}, policy)
+}
+
+// SendScreenWait sends a single screen to the LED board and waits for it to be acknowledged.
+func (c *Client) SendScreenWait(ctx context.Context, screen *Screen, policy Policy) ([]Result, error) {
+	encoded, err := screen.Encode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode screen: %w", err)
+	}
+	return c.requestAll(ctx, func(t *target) string {
+		return t.buildDatagram(DefaultFile, StorageRAM, encoded)
+	}, policy)
+}
+
+// ReadDate reads the clock of the LED board, found in Result.Packet.Date.
+func (c *Client) ReadDate(ctx context.Context, policy Policy) ([]Result, error) {
+	return c.requestAll(ctx, func(t *target) string {
+		return t.sign.packet(CommandReadFunction + FunctionDate)
+	}, policy)
+}
+
+// ReadMemory reads the memory configuration of the LED board, found in Result.Packet.Memory.
+func (c *Client) ReadMemory(ctx context.Context, policy Policy) ([]Result, error) {
+	return c.requestAll(ctx, func(t *target) string {
+		return t.sign.packet(CommandReadFunction + FunctionMemory)
+	}, policy)
+}
+
+// ReadFile reads a text file of the LED board, found in Result.Packet.Screen.
+func (c *Client) ReadFile(ctx context.Context, label string, policy Policy) ([]Result, error) {
+	return c.requestAll(ctx, func(t *target) string {
+		return t.sign.packet(CommandReadText + label)
+	}, policy)
+}
+
+// ReadString reads a string file of the LED board, found in Result.Packet.Value.
+func (c *Client) ReadString(ctx context.Context, label string, policy Policy) ([]Result, error) {
+	return c.requestAll(ctx, func(t *target) string {
+		return t.sign.packet(CommandReadString + label)
+	}, policy)
+}
 
-	cmd += utils.Byte2Hex(byte(date.Year()%100)) + utils.Byte2Hex(byte(date.Year()/100))
-	cmd += utils.Byte2Hex(byte(date.Month()))
-	cmd += utils.Byte2Hex(byte(date.Day()))
-	cmd += utils.Byte2Hex(byte(date.Hour()))
-	cmd += utils.Byte2Hex(byte(date.Minute()))
-	cmd += utils.Byte2Hex(0)
-	cmd += utils.Byte2Hex(0)
+// SetDate sets the date on the LED board.
+func (c *Client) SetDate(date time.Time) error {
+	slog.Info("pushing datetime", "time", date)
+	return c.enqueue("date", func(t *target) string {
+		return t.sign.packet(DateFunction(date))
+	})
+}
 
-	cmd += ControlEnd
+// SetBrightness dims the LED board to the percentage of its full brightness.
+func (c *Client) SetBrightness(percent int) error {
+	slog.Info("setting brightness", "percent", percent)
+	return c.enqueue("brightness", func(t *target) string {
+		return t.sign.packet(BrightnessFunction(percent))
+	})
+}
 
-	c.Send(cmd)
+// SendScreen sends a single screen to the LED board, replacing the default file.
+func (c *Client) SendScreen(screen *Screen) error {
+	return c.WriteFile(DefaultFile, StorageRAM, screen)
 }
 
-// SendScreen sends a single screen command to the LED board.
-func (c *Client) SendScreen(screen string) {
-	datagram := c.buildDatagram(screen)
-	c.Send(datagram)
+// WriteFile stores the screen as the text file with the given label, either
+// in RAM or in flash, where it survives a power cycle. Files other than the
+// default file need to be allocated first.
+func (c *Client) WriteFile(label, storage string, screen *Screen) error {
+	encoded, err := screen.Encode()
+	if err != nil {
+		return fmt.Errorf("failed to encode screen for file %s: %w", label, err)
+	}
+	return c.enqueue("text:"+label, func(t *target) string {
+		return t.buildDatagram(label, storage, encoded)
+	})
 }
 
-// SendScreens sends multiple screen commands to the LED board, joined by a frame.
-func (c *Client) SendScreens(screens []string) {
-	combinedScreen := ""
-	for i, screen := range screens {
-		combinedScreen += screen
-		if i < len(screens)-1 {
-			combinedScreen += ControlFrame
+// WriteString sets the value of the string file with the given label, which
+// needs to be allocated first. Text files showing it with Frame.Variable
+// show the new value right away, without starting over.
+func (c *Client) WriteString(label, value string) error {
+	for i := 0; i < len(value); i++ {
+		if value[i] < 0x20 || value[i] > 0x7E {
+			return fmt.Errorf("string %s: invalid character %q", label, value[i])
 		}
 	}
-	c.SendScreen(combinedScreen)
+	return c.enqueue("string:"+label, func(t *target) string {
+		return t.sign.packet(StringFile(label, value))
+	})
+}
+
+// WritePicture stores the picture as the picture file with the given label,
+// which needs to be allocated first. Screens show it with Frame.Picture.
+func (c *Client) WritePicture(label string, picture *Picture) error {
+	file, err := PictureFile(label, picture)
+	if err != nil {
+		return fmt.Errorf("failed to encode picture %s: %w", label, err)
+	}
+	return c.enqueue("picture:"+label, func(t *target) string {
+		return t.sign.packet(file)
+	})
+}
+
+// AllocateMemory replaces the memory configuration of the LED board. This
+// erases all files that are stored in RAM.
+func (c *Client) AllocateMemory(allocations []FileAllocation) error {
+	slog.Info("allocating memory", "files", len(allocations))
+	return c.enqueue("memory", func(t *target) string {
+		return t.sign.packet(MemoryFunction(allocations))
+	})
+}
+
+// UploadFonts stores the fonts of the set in their custom font slots.
+func (c *Client) UploadFonts(fonts *FontSet) error {
+	var errs []error
+	custom := fonts.Fonts()
+	for _, slot := range slices.Sorted(maps.Keys(custom)) {
+		font := custom[slot]
+		slog.Info("uploading font", "slot", slot, "glyphs", len(font.Glyphs))
+		errs = append(errs, c.enqueue("font:"+string(slot), func(t *target) string {
+			return t.sign.packet(FontFunction(slot, font))
+		}))
+	}
+	return errors.Join(errs...)
+}
+
+// SetRunSequence sets the text files the LED board shows, one after another.
+func (c *Client) SetRunSequence(labels ...string) error {
+	slog.Info("setting run sequence", "labels", labels)
+	return c.enqueue("sequence", func(t *target) string {
+		return t.sign.packet(RunSequenceFunction(labels...))
+	})
 }
 
-func (c *Client) buildDatagram(screen string) string {
-	var cmd string
-	cmd = "\x01Z00\x02A"
-	cmd += "\x0fETAA" // store to RAM
+// SendScreens sends multiple screens to the LED board, joined into a single screen.
+func (c *Client) SendScreens(screens []*Screen) error {
+	return c.SendScreen(Join(screens...))
+}
 
-	cmd += screen
-	cmd += ControlEnd
+// enqueue queues the datagram built per sign for every sign of the client.
+// Errors are those of queueing, packets that fail to be written later are
+// counted in Stats.
+func (c *Client) enqueue(key string, build func(t *target) string) error {
+	var errs []error
+	for _, t := range c.targets {
+		if _, err := t.enqueue(key, build(t), c.trigger, nil); err != nil {
+			errs = append(errs, fmt.Errorf("sign %s: %w", t.sign.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
 
-	return cmd
+func (t *target) buildDatagram(label, storage, screen string) string {
+	return t.sign.packet(TextFile(label, storage, screen))
 }
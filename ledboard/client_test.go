@@ -0,0 +1,106 @@
+package ledboard_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+)
+
+// startSlowSign serves a sign on a local UDP port that answers every packet
+// after the delay, rejecting all but the date.
+func startSlowSign(t *testing.T, delay time.Duration) ledboard.Sign {
+	t.Helper()
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go func() {
+		buf := make([]byte, 65536)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			answer := ledboard.PacketNak
+			if packet, err := ledboard.Decode(string(buf[:n])); err == nil && packet.Function == ledboard.FunctionDate {
+				answer = ledboard.PacketAck
+			}
+			time.AfterFunc(delay, func() { _, _ = conn.WriteTo([]byte(answer), from) })
+		}
+	}()
+
+	sign, err := ledboard.ParseSign("udp://" + conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sign
+}
+
+func TestRequestAfterQueuedSends(t *testing.T) {
+	sign := startSlowSign(t, 2*time.Millisecond)
+	client, err := ledboard.NewClient([]ledboard.Sign{sign}, nil, ledboard.QueueOptions{Gap: 10 * time.Millisecond, Size: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// The rejections of the packets queued before the date arrive after
+	// the date was requested, they are no answer to it.
+	for percent := range 5 {
+		if err := client.Send(sign.Packet(ledboard.BrightnessFunction(50 + percent))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	results, err := client.SetDateWait(context.Background(), time.Now(), ledboard.Policy{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := results[0].Status; status != ledboard.StatusAcked {
+		t.Errorf("status = %s, want %s", status, ledboard.StatusAcked)
+	}
+}
+
+func TestSendKeepsGap(t *testing.T) {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	sign, err := ledboard.ParseSign("udp://" + conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const gap, packets = 20 * time.Millisecond, 4
+	client, err := ledboard.NewClient([]ledboard.Sign{sign}, nil, ledboard.QueueOptions{Gap: gap, Size: packets})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	for percent := range packets {
+		if err := client.Send(sign.Packet(ledboard.BrightnessFunction(50 + percent))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buf := make([]byte, 1024)
+	var last time.Time
+	for i := range packets {
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		if _, _, err := conn.ReadFrom(buf); err != nil {
+			t.Fatalf("packet %d: %v", i, err)
+		}
+		now := time.Now()
+		// Allow for the scheduling of the reader, the writer sleeps the
+		// whole gap.
+		if i > 0 && now.Sub(last) < gap*9/10 {
+			t.Errorf("packet %d arrived %s after the previous one, want at least %s", i, now.Sub(last), gap)
+		}
+		last = now
+	}
+}
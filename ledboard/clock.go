@@ -0,0 +1,160 @@
+package ledboard
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ClockSync keeps the clocks of the signs in line with the host's clock in
+// the configured time zone.
+type ClockSync struct {
+	client   *Client
+	location *time.Location
+	interval time.Duration
+
+	// mu makes sure no date is pushed once Pause returned.
+	mu     sync.Mutex
+	paused bool
+
+	resync chan struct{}
+}
+
+// NewClockSync creates a clock sync for the client's signs, resyncing every
+// interval.
+func NewClockSync(client *Client, location *time.Location, interval time.Duration) *ClockSync {
+	return &ClockSync{
+		client:   client,
+		location: location,
+		interval: interval,
+		resync:   make(chan struct{}, 1),
+	}
+}
+
+// Run syncs the clocks every interval, right after daylight saving time
+// transitions and whenever Resync is called, until the context is done.
+func (s *ClockSync) Run(ctx context.Context) error {
+	for {
+		now := time.Now()
+		wait := s.interval
+		if transition, ok := nextTransition(s.location, now, now.Add(s.interval)); ok {
+			slog.Debug("scheduling clock sync at time zone transition", "time", transition.In(s.location))
+			wait = transition.Sub(now)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-s.resync:
+			timer.Stop()
+		case <-timer.C:
+		}
+		s.sync(ctx)
+	}
+}
+
+// Resync syncs the clocks as soon as possible, e.g. when a sign came online.
+func (s *ClockSync) Resync() {
+	select {
+	case s.resync <- struct{}{}:
+	default:
+	}
+}
+
+// Pause stops pushing the time, while the sign's clock is used for something
+// else like a stopwatch.
+func (s *ClockSync) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = true
+}
+
+// Resume pushes the time again, starting right away.
+func (s *ClockSync) Resume() {
+	s.mu.Lock()
+	s.paused = false
+	s.mu.Unlock()
+	s.Resync()
+}
+
+func (s *ClockSync) sync(ctx context.Context) {
+	if s.isPaused() {
+		slog.Debug("clock sync is paused")
+		return
+	}
+	s.measureDrift(ctx)
+
+	// The sign's clock counts whole seconds, the time is sent as a second
+	// starts so it is off by the transmission delay only.
+	now := time.Now()
+	next := now.Truncate(time.Second).Add(time.Second)
+	timer := time.NewTimer(next.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return
+	case <-timer.C:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.paused {
+		return
+	}
+	if err := s.client.SetDate(next.In(s.location)); err != nil {
+		slog.Error("failed to sync LED board clock", "error", err)
+	}
+}
+
+func (s *ClockSync) isPaused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused
+}
+
+// measureDrift reads the clocks of the signs and logs how far they are off.
+// Signs not answering read requests are skipped.
+func (s *ClockSync) measureDrift(ctx context.Context) {
+	results, err := s.client.ReadDate(ctx, Policy{Timeout: time.Second})
+	if err != nil {
+		slog.Debug("unable to read LED board clock", "error", err)
+	}
+	local := time.Now().In(s.location)
+	// The sign knows the wall clock only, compare it as UTC like the decoder.
+	wall := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), 0, time.UTC)
+
+	for _, result := range results {
+		if result.Status != StatusAcked || result.Packet == nil || result.Packet.Date.IsZero() {
+			slog.Debug("LED board clock not available", "sign", result.Sign.Name, "status", result.Status)
+			continue
+		}
+		drift := result.Packet.Date.Sub(wall)
+		slog.Info("LED board clock drift", "sign", result.Sign.Name, "drift", drift, "clock", result.Packet.Date.Format(time.DateTime))
+	}
+}
+
+// nextTransition finds the first daylight saving time or other offset change
+// of the location between from and to, to the second.
+func nextTransition(location *time.Location, from, to time.Time) (time.Time, bool) {
+	offset := func(t time.Time) int {
+		_, offset := t.In(location).Zone()
+		return offset
+	}
+	before := offset(from)
+	if offset(to) == before {
+		return time.Time{}, false
+	}
+
+	for to.Sub(from) > time.Second {
+		mid := from.Add(to.Sub(from) / 2)
+		if offset(mid) == before {
+			from = mid
+		} else {
+			to = mid
+		}
+	}
+	return to.Truncate(time.Second), true
+}
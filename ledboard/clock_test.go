@@ -0,0 +1,83 @@
+package ledboard
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextTransition(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	utc := func(month time.Month, day, hour, min, sec, nsec int) time.Time {
+		return time.Date(2026, month, day, hour, min, sec, nsec, time.UTC)
+	}
+	tests := []struct {
+		name     string
+		location *time.Location
+		from, to time.Time
+		want     time.Time
+		ok       bool
+	}{
+		{
+			name:     "spring forward",
+			location: berlin,
+			from:     utc(time.March, 28, 23, 0, 0, 0),
+			to:       utc(time.March, 29, 23, 0, 0, 0),
+			want:     utc(time.March, 29, 1, 0, 0, 0),
+			ok:       true,
+		},
+		{
+			name:     "fall back",
+			location: berlin,
+			from:     utc(time.October, 24, 12, 0, 0, 0),
+			to:       utc(time.October, 25, 12, 0, 0, 0),
+			want:     utc(time.October, 25, 1, 0, 0, 0),
+			ok:       true,
+		},
+		{
+			name:     "off the second",
+			location: berlin,
+			from:     utc(time.October, 25, 0, 59, 58, 123456789),
+			to:       utc(time.October, 25, 1, 0, 3, 987654321),
+			want:     utc(time.October, 25, 1, 0, 0, 0),
+			ok:       true,
+		},
+		{
+			name:     "right at the transition",
+			location: berlin,
+			from:     utc(time.March, 29, 0, 59, 59, 0),
+			to:       utc(time.March, 29, 1, 0, 0, 0),
+			want:     utc(time.March, 29, 1, 0, 0, 0),
+			ok:       true,
+		},
+		{
+			name:     "just before the transition",
+			location: berlin,
+			from:     utc(time.March, 29, 0, 0, 0, 0),
+			to:       utc(time.March, 29, 0, 59, 59, 0),
+		},
+		{
+			name:     "summer",
+			location: berlin,
+			from:     utc(time.July, 1, 0, 0, 0, 0),
+			to:       utc(time.July, 31, 0, 0, 0, 0),
+		},
+		{
+			name:     "UTC",
+			location: time.UTC,
+			from:     utc(time.March, 1, 0, 0, 0, 0),
+			to:       utc(time.November, 1, 0, 0, 0, 0),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := nextTransition(test.location, test.from, test.to)
+			if ok != test.ok || !got.Equal(test.want) {
+				t.Errorf("nextTransition = %s, %t, want %s, %t", got, ok, test.want, test.ok)
+			}
+		})
+	}
+}
@@ -1,5 +1,29 @@
 package ledboard
 
+// Font selects one of the sign's character sets.
+type Font string
+
+// FontColor selects the color text is drawn in.
+type FontColor string
+
+// BackgroundColor selects the color behind the text.
+type BackgroundColor string
+
+// Pattern selects the animation used to move a frame in or out.
+type Pattern string
+
+// Special selects a value the sign fills in itself, like the current time.
+type Special string
+
+// Speed selects how fast patterns are animated.
+type Speed string
+
+// HorizontalAlign selects the horizontal text alignment.
+type HorizontalAlign string
+
+// VerticalAlign selects the vertical text alignment.
+type VerticalAlign string
+
 const (
 	// Control commands
 	ControlHead            = "\x01\x5A\x30\x30\x02\x41\x58"
@@ -16,65 +40,109 @@ const (
 	ControlBackgroundColor = "\x1D"
 	ControlAlignHorizontal = "\x1E"
 	ControlAlignVertical   = "\x1F"
+	ControlFont            = "\x1A"
+	ControlPause           = "\x0E"
+	ControlPicture         = "\x14"
+	ControlString          = "\x10"
+
+	// Packet commands
+	PacketStartOfHeader = "\x01"
+	PacketStartOfText   = "\x02"
+	PacketTypeAll       = "Z"
+	PacketAddressAll    = "00"
+	PacketEndOfText     = "\x03"
+	PacketTypeResponse  = "0"
+	PacketAck           = "\x06"
+	PacketNak           = "\x15"
+
+	// Command codes
+	CommandWriteText       = "A"
+	CommandSpecialFunction = "E"
+	CommandReadText        = "B"
+	CommandReadFunction    = "F"
+	CommandWritePicture    = "I"
+	CommandWriteString     = "G"
+	CommandReadString      = "H"
+
+	// Special function codes
+	FunctionDate        = "B"
+	FunctionMemory      = "$"
+	FunctionRunSequence = "."
+	FunctionFont        = ";"
+	FunctionBrightness  = "/"
+	FunctionSoftReset   = ","
+	FunctionBeep        = "("
+	FunctionConfig      = "!"
+	FunctionTestPattern = "*"
+
+	// File commands
+	FileText = "\x0fET"
+
+	// File storage
+	StorageRAM   = "A"
+	StorageFlash = "F"
+
+	// Run sequence modes
+	RunSequenceInOrder = "TU"
 
 	// Flash commands
 	FlashOff = "\x30"
 	FlashOn  = "\x31"
 
 	// Special commands
-	SpecialMMDDYYSLA   = "\x20"
-	SpecialDDMMYYSLA   = "\x21"
-	SpecialMMDDYYDSH   = "\x22"
-	SpecialDDMMYYDSH   = "\x23"
-	SpecialMMDDYYYYDOT = "\x24"
-	SpecialYY          = "\x25"
-	SpecialYYYY        = "\x26"
-	SpecialMM          = "\x27"
-	SpecialMMM         = "\x28"
-	SpecialDD          = "\x29"
-	SpecialDDOfWeek    = "\x2A"
-	SpecialDDDOfWeek   = "\x2B"
-	SpecialHH          = "\x2C"
-	SpecialMIN         = "\x2D"
-	SpecialSEC         = "\x2E"
-	SpecialHHMin24     = "\x2F"
-	SpecialHHMin12     = "\x30"
+	SpecialMMDDYYSLA   Special = "\x20"
+	SpecialDDMMYYSLA   Special = "\x21"
+	SpecialMMDDYYDSH   Special = "\x22"
+	SpecialDDMMYYDSH   Special = "\x23"
+	SpecialMMDDYYYYDOT Special = "\x24"
+	SpecialYY          Special = "\x25"
+	SpecialYYYY        Special = "\x26"
+	SpecialMM          Special = "\x27"
+	SpecialMMM         Special = "\x28"
+	SpecialDD          Special = "\x29"
+	SpecialDDOfWeek    Special = "\x2A"
+	SpecialDDDOfWeek   Special = "\x2B"
+	SpecialHH          Special = "\x2C"
+	SpecialMIN         Special = "\x2D"
+	SpecialSEC         Special = "\x2E"
+	SpecialHHMin24     Special = "\x2F"
+	SpecialHHMin12     Special = "\x30"
 
 	// Pattern commands
-	PatternRandom                  = "\x2F"
-	PatternJumpOut                 = "\x30"
-	PatternMoveLeft                = "\x31"
-	PatternMoveRight               = "\x32"
-	PatternScrollLeft              = "\x33"
-	PatternScrollRight             = "\x34"
-	PatternMoveUp                  = "\x35"
-	PatternMoveDown                = "\x36"
-	PatternScrollLR                = "\x37"
-	PatternScrollUp                = "\x38"
-	PatternScrollDown              = "\x39"
-	PatternFoldLR                  = "\x3A"
-	PatternFoldUD                  = "\x3B"
-	PatternScrollUD                = "\x3C"
-	PatternShuttleLR               = "\x3D"
-	PatternShuttleUD               = "\x3E"
-	PatternPeelOffL                = "\x3F"
-	PatternPeelOffR                = "\x40"
-	PatternShutterUD               = "\x41"
-	PatternShutterLR               = "\x42"
-	PatternRaindrops               = "\x43"
-	PatternRandomMosaic            = "\x44"
-	PatternTwinklingStar           = "\x45"
-	PatternHipHop                  = "\x46"
-	PatternRadarScan               = "\x47"
-	PatternFanOut                  = "\x48"
-	PatternFanIn                   = "\x49"
-	PatternSpiralR                 = "\x4A"
-	PatternSpiralL                 = "\x4B"
-	PatternToFourCorners           = "\x4C"
-	PatternFromFourCorners         = "\x4D"
-	PatternToFourSides             = "\x4E"
-	PatternFromFourSides           = "\x4F"
-	PatternScrollOutFromFourBlocks = "\x50"
+	PatternRandom                  Pattern = "\x2F"
+	PatternJumpOut                 Pattern = "\x30"
+	PatternMoveLeft                Pattern = "\x31"
+	PatternMoveRight               Pattern = "\x32"
+	PatternScrollLeft              Pattern = "\x33"
+	PatternScrollRight             Pattern = "\x34"
+	PatternMoveUp                  Pattern = "\x35"
+	PatternMoveDown                Pattern = "\x36"
+	PatternScrollLR                Pattern = "\x37"
+	PatternScrollUp                Pattern = "\x38"
+	PatternScrollDown              Pattern = "\x39"
+	PatternFoldLR                  Pattern = "\x3A"
+	PatternFoldUD                  Pattern = "\x3B"
+	PatternScrollUD                Pattern = "\x3C"
+	PatternShuttleLR               Pattern = "\x3D"
+	PatternShuttleUD               Pattern = "\x3E"
+	PatternPeelOffL                Pattern = "\x3F"
+	PatternPeelOffR                Pattern = "\x40"
+	PatternShutterUD               Pattern = "\x41"
+	PatternShutterLR               Pattern = "\x42"
+	PatternRaindrops               Pattern = "\x43"
+	PatternRandomMosaic            Pattern = "\x44"
+	PatternTwinklingStar           Pattern = "\x45"
+	PatternHipHop                  Pattern = "\x46"
+	PatternRadarScan               Pattern = "\x47"
+	PatternFanOut                  Pattern = "\x48"
+	PatternFanIn                   Pattern = "\x49"
+	PatternSpiralR                 Pattern = "\x4A"
+	PatternSpiralL                 Pattern = "\x4B"
+	PatternToFourCorners           Pattern = "\x4C"
+	PatternFromFourCorners         Pattern = "\x4D"
+	PatternToFourSides             Pattern = "\x4E"
+	PatternFromFourSides           Pattern = "\x4F"
+	PatternScrollOutFromFourBlocks Pattern = "\x50"
 
 	// Pause commands
 	PauseSecond2      = "\x0E\x30"
@@ -83,61 +151,61 @@ const (
 	PauseMillisecond4 = "\x0E\x33"
 
 	// Align commands
-	AlignHorizontalCenter   = "\x30"
-	AlignHorizontalLeft     = "\x31"
-	AlignHorizontalRight    = "\x32"
-	AlignHorizontalReserved = "\x33"
-	AlignVerticalCenter     = "\x30"
-	AlignVerticalTop        = "\x31"
-	AlignVerticalBottom     = "\x32"
-	AlignVerticalReserved   = "\x33"
+	AlignHorizontalCenter   HorizontalAlign = "\x30"
+	AlignHorizontalLeft     HorizontalAlign = "\x31"
+	AlignHorizontalRight    HorizontalAlign = "\x32"
+	AlignHorizontalReserved HorizontalAlign = "\x33"
+	AlignVerticalCenter     VerticalAlign   = "\x30"
+	AlignVerticalTop        VerticalAlign   = "\x31"
+	AlignVerticalBottom     VerticalAlign   = "\x32"
+	AlignVerticalReserved   VerticalAlign   = "\x33"
 
 	// BackgroundColor commands
-	BackgroundColorBlack  = "\x30"
-	BackgroundColorRed    = "\x31"
-	BackgroundColorGreen  = "\x32"
-	BackgroundColorYellow = "\x33"
+	BackgroundColorBlack  BackgroundColor = "\x30"
+	BackgroundColorRed    BackgroundColor = "\x31"
+	BackgroundColorGreen  BackgroundColor = "\x32"
+	BackgroundColorYellow BackgroundColor = "\x33"
 
 	// Font commands
-	FontNormal5x5   = "\x1A\x30"
-	FontNormal7x6   = "\x1A\x31"
-	FontNormal14x8  = "\x1A\x32"
-	FontNormal11x9  = "\x1A\x3A"
-	FontNormal15x9  = "\x1A\x33"
-	FontNormal16x9  = "\x1A\x34"
-	FontNormal24x16 = "\x1A\x36"
-	FontNormal22x18 = "\x1A\x3C"
-	FontNormal30x18 = "\x1A\x3D"
-	FontNormal32x18 = "\x1A\x38"
-	FontNormal40x21 = "\x1A\x3E"
-	FontBold5x7     = "\x1A\x4D"
-	FontBold14x10   = "\x1A\x4E"
-	FontBold15x10   = "\x1A\x4F"
-	FontBold16x12   = "\x1A\x50"
-	FontCustom3     = "\x1A\x63"
-	FontCustom4     = "\x1A\x64"
-	FontCustom5     = "\x1A\x65"
-	FontCustom6     = "\x1A\x66"
-	FontCustom7     = "\x1A\x67"
-	FontCustom8     = "\x1A\x68"
-	FontCustom9     = "\x1A\x69"
+	FontNormal5x5   Font = "\x1A\x30"
+	FontNormal7x6   Font = "\x1A\x31"
+	FontNormal14x8  Font = "\x1A\x32"
+	FontNormal11x9  Font = "\x1A\x3A"
+	FontNormal15x9  Font = "\x1A\x33"
+	FontNormal16x9  Font = "\x1A\x34"
+	FontNormal24x16 Font = "\x1A\x36"
+	FontNormal22x18 Font = "\x1A\x3C"
+	FontNormal30x18 Font = "\x1A\x3D"
+	FontNormal32x18 Font = "\x1A\x38"
+	FontNormal40x21 Font = "\x1A\x3E"
+	FontBold5x7     Font = "\x1A\x4D"
+	FontBold14x10   Font = "\x1A\x4E"
+	FontBold15x10   Font = "\x1A\x4F"
+	FontBold16x12   Font = "\x1A\x50"
+	FontCustom3     Font = "\x1A\x63"
+	FontCustom4     Font = "\x1A\x64"
+	FontCustom5     Font = "\x1A\x65"
+	FontCustom6     Font = "\x1A\x66"
+	FontCustom7     Font = "\x1A\x67"
+	FontCustom8     Font = "\x1A\x68"
+	FontCustom9     Font = "\x1A\x69"
 
 	// FontColor commands
-	FontColorBlack         = "\x30"
-	FontColorRed           = "\x31"
-	FontColorGreen         = "\x32"
-	FontColorYellow        = "\x33"
-	FontColorYGRCharacter  = "\x34"
-	FontColorYGRHorizontal = "\x35"
-	FontColorYGRWave       = "\x36"
-	FontColorYGRDiagonal   = "\x37"
+	FontColorBlack         FontColor = "\x30"
+	FontColorRed           FontColor = "\x31"
+	FontColorGreen         FontColor = "\x32"
+	FontColorYellow        FontColor = "\x33"
+	FontColorYGRCharacter  FontColor = "\x34"
+	FontColorYGRHorizontal FontColor = "\x35"
+	FontColorYGRWave       FontColor = "\x36"
+	FontColorYGRDiagonal   FontColor = "\x37"
 
 	// Speed commands
-	SpeedVeryFast   = "\x30"
-	SpeedFast       = "\x31"
-	SpeedMediumFast = "\x32"
-	SpeedMedium     = "\x33"
-	SpeedMediumSlow = "\x34"
-	SpeedSlow       = "\x35"
-	SpeedVerySlow   = "\x36"
+	SpeedVeryFast   Speed = "\x30"
+	SpeedFast       Speed = "\x31"
+	SpeedMediumFast Speed = "\x32"
+	SpeedMedium     Speed = "\x33"
+	SpeedMediumSlow Speed = "\x34"
+	SpeedSlow       Speed = "\x35"
+	SpeedVerySlow   Speed = "\x36"
 )
@@ -0,0 +1,714 @@
+package ledboard
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/pixelfont"
+)
+
+// Packet is the structured form of a datagram sent to the LED board.
+type Packet struct {
+	Type    string
+	Address string
+	Command string
+	// Checksum is set for packets framed by a checksum.
+	Checksum bool
+
+	// Set for write and read text packets.
+	Label   string
+	Storage string
+	Screen  *Screen
+
+	// Set for write picture packets, along with Label.
+	Picture *Picture
+
+	// Set for write string packets, along with Label.
+	Value string
+
+	// Set for special function packets and read function requests. The
+	// Weekday sent along with Date is counted as SignWeekday does.
+	Function   string
+	Date       time.Time
+	Weekday    int
+	Memory     []FileAllocation
+	Sequence   []string
+	FontSlot   Font
+	Font       *CustomFont
+	Brightness int
+	Beep       Beep
+	Config     SignConfig
+	// TestPattern is set when the test pattern is started, not stopped.
+	TestPattern bool
+}
+
+// DecodeError describes why and where a datagram could not be decoded.
+type DecodeError struct {
+	Offset int
+	Reason string
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("offset %d: %s", e.Offset, e.Reason)
+}
+
+type decoder struct {
+	data string
+	pos  int
+}
+
+// Decode parses a raw datagram, as built by the Client, into a Packet.
+func Decode(datagram string) (*Packet, error) {
+	d := &decoder{data: datagram}
+	packet := &Packet{}
+
+	if err := d.expect(PacketStartOfHeader, "start of header"); err != nil {
+		return nil, err
+	}
+	header, err := d.take(3, "type and address")
+	if err != nil {
+		return nil, err
+	}
+	packet.Type, packet.Address = header[:1], header[1:]
+	if err := d.expect(PacketStartOfText, "start of text"); err != nil {
+		return nil, err
+	}
+	if packet.Command, err = d.take(1, "command code"); err != nil {
+		return nil, err
+	}
+
+	switch packet.Command {
+	case CommandWriteText:
+		err = d.decodeWriteText(packet)
+	case CommandSpecialFunction:
+		err = d.decodeSpecialFunction(packet)
+	case CommandWritePicture:
+		err = d.decodeWritePicture(packet)
+	case CommandWriteString:
+		err = d.decodeWriteString(packet)
+	case CommandReadText:
+		packet.Label, packet.Storage, err = d.decodeFile()
+	case CommandReadString:
+		packet.Label, err = d.take(1, "string file label")
+	case CommandReadFunction:
+		packet.Function, err = d.take(1, "special function code")
+		if err == nil && packet.Function != FunctionDate && packet.Function != FunctionMemory && packet.Function != FunctionConfig {
+			err = d.errorf(-1, "unknown special function code %q", packet.Function)
+		}
+	default:
+		err = d.errorf(-1, "unknown command code %q", packet.Command)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if d.pos < len(d.data) && d.data[d.pos:d.pos+1] == PacketEndOfText {
+		if err := d.checksum(); err != nil {
+			return nil, err
+		}
+		packet.Checksum = true
+	}
+	if err := d.expect(ControlEnd, "end of transmission"); err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, d.errorf(0, "%d trailing bytes after end of transmission", len(d.data)-d.pos)
+	}
+	return packet, nil
+}
+
+// atBodyEnd reports whether the command's body ended, at end of
+// transmission or at end of text followed by a checksum.
+func (d *decoder) atBodyEnd() bool {
+	if d.pos >= len(d.data) {
+		return true
+	}
+	c := d.data[d.pos : d.pos+1]
+	return c == ControlEnd || c == PacketEndOfText
+}
+
+// checksum checks the checksum following end of text. It sums up the bytes
+// from start of text, which follows the header, up to end of text.
+func (d *decoder) checksum() error {
+	const header = 4
+	end := d.pos
+	d.pos++
+	sum, err := d.take(4, "checksum")
+	if err != nil {
+		return err
+	}
+	if want := checksum(d.data[header : end+1]); sum != want {
+		return d.errorf(-4, "checksum %q does not match %q", sum, want)
+	}
+	return nil
+}
+
+func (d *decoder) decodeWriteText(packet *Packet) error {
+	label, storage, err := d.decodeFile()
+	if err != nil {
+		return err
+	}
+	packet.Label, packet.Storage = label, storage
+
+	screen, err := d.decodeScreen()
+	if err != nil {
+		return err
+	}
+	packet.Screen = screen
+	return nil
+}
+
+func (d *decoder) decodeWriteString(packet *Packet) error {
+	var err error
+	if packet.Label, err = d.take(1, "string file label"); err != nil {
+		return err
+	}
+	start := d.pos
+	for !d.atBodyEnd() {
+		if c := d.data[d.pos]; c < 0x20 || c > 0x7E {
+			return d.errorf(0, "invalid string character %q", c)
+		}
+		d.pos++
+	}
+	packet.Value = d.data[start:d.pos]
+	return nil
+}
+
+func (d *decoder) decodeWritePicture(packet *Packet) error {
+	var err error
+	if packet.Label, err = d.take(1, "picture label"); err != nil {
+		return err
+	}
+	height, err := d.hex(2, "picture height")
+	if err != nil {
+		return err
+	}
+	width, err := d.hex(2, "picture width")
+	if err != nil {
+		return err
+	}
+
+	packet.Picture = NewPicture(width, height)
+	for y := 0; y < height; y++ {
+		row, err := d.take(width, "picture row")
+		if err != nil {
+			return err
+		}
+		for x := 0; x < width; x++ {
+			c := PixelColor(row[x])
+			if c < PixelOff || c > PixelAmber {
+				return d.errorf(x-width, "invalid pixel color %q", row[x])
+			}
+			packet.Picture.Set(x, y, c)
+		}
+		if err := d.expect(ControlLineFeed, "end of picture row"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeFile reads a file label, either on its own or as part of a text file
+// specification that also names the storage.
+func (d *decoder) decodeFile() (string, string, error) {
+	if !strings.HasPrefix(d.data[d.pos:], FileText) {
+		label, err := d.take(1, "file label")
+		return label, StorageRAM, err
+	}
+
+	d.pos += len(FileText)
+	file, err := d.take(2, "file label and storage")
+	if err != nil {
+		return "", "", err
+	}
+	label, storage := file[:1], file[1:]
+	if storage != StorageRAM && storage != StorageFlash {
+		return "", "", d.errorf(-1, "unknown storage %q", storage)
+	}
+	return label, storage, nil
+}
+
+func (d *decoder) decodeSpecialFunction(packet *Packet) error {
+	function, err := d.take(1, "special function code")
+	if err != nil {
+		return err
+	}
+	packet.Function = function
+
+	switch function {
+	case FunctionDate:
+		var fields [8]int
+		for i := range fields {
+			if fields[i], err = d.bcd(); err != nil {
+				return err
+			}
+		}
+		year := fields[1]*100 + fields[0]
+		packet.Date = time.Date(year, time.Month(fields[2]), fields[3], fields[4], fields[5], fields[6], 0, time.UTC)
+		packet.Weekday = fields[7]
+	case FunctionRunSequence:
+		if err := d.expect(RunSequenceInOrder, "run sequence mode"); err != nil {
+			return err
+		}
+		for !d.atBodyEnd() {
+			label, _ := d.take(1, "file label")
+			packet.Sequence = append(packet.Sequence, label)
+		}
+	case FunctionMemory:
+		for !d.atBodyEnd() {
+			allocation, err := d.decodeFileAllocation()
+			if err != nil {
+				return err
+			}
+			packet.Memory = append(packet.Memory, allocation)
+		}
+	case FunctionFont:
+		return d.decodeFont(packet)
+	case FunctionBrightness:
+		if packet.Brightness, err = d.hex(2, "brightness"); err != nil {
+			return err
+		}
+		if packet.Brightness > MaxBrightness {
+			return d.errorf(-2, "brightness %d%% out of range", packet.Brightness)
+		}
+	case FunctionSoftReset:
+	case FunctionBeep:
+		return d.decodeBeep(packet)
+	case FunctionConfig:
+		return d.decodeConfig(packet)
+	case FunctionTestPattern:
+		on, err := d.take(1, "test pattern switch")
+		if err != nil {
+			return err
+		}
+		if on != FlashOn && on != FlashOff {
+			return d.errorf(-1, "invalid test pattern switch %q", on)
+		}
+		packet.TestPattern = on == FlashOn
+	default:
+		return d.errorf(-1, "unknown special function code %q", function)
+	}
+	return nil
+}
+
+func (d *decoder) decodeBeep(packet *Packet) error {
+	if err := d.expect(beepProgrammable, "programmable beep"); err != nil {
+		return err
+	}
+	frequency, err := d.hex(2, "beep frequency")
+	if err != nil {
+		return err
+	}
+	if frequency > MaxBeepFrequency {
+		return d.errorf(-2, "beep frequency %d out of range", frequency)
+	}
+	tenths, err := d.hex(1, "beep duration")
+	if err != nil {
+		return err
+	}
+	if tenths == 0 {
+		return d.errorf(-1, "beep duration must not be zero")
+	}
+	repeats, err := d.hex(1, "beep repeats")
+	if err != nil {
+		return err
+	}
+	packet.Beep = Beep{Frequency: frequency, Duration: time.Duration(tenths) * 100 * time.Millisecond, Repeats: repeats}
+	return nil
+}
+
+func (d *decoder) decodeConfig(packet *Packet) error {
+	speaker, err := d.take(2, "speaker switch")
+	if err != nil {
+		return err
+	}
+	switch speaker {
+	case speakerOn, speakerOff:
+		packet.Config.Speaker = speaker == speakerOn
+	default:
+		return d.errorf(-2, "invalid speaker switch %q", speaker)
+	}
+	format, err := d.take(1, "time format")
+	if err != nil {
+		return err
+	}
+	switch format {
+	case timeFormat24, timeFormat12:
+		packet.Config.Clock24 = format == timeFormat24
+	default:
+		return d.errorf(-1, "invalid time format %q", format)
+	}
+	return nil
+}
+
+func (d *decoder) decodeScreen() (*Screen, error) {
+	screen := NewScreen()
+	frame := screen.AddFrame()
+
+	for d.pos < len(d.data) {
+		start := d.pos
+		c := d.data[d.pos]
+
+		// The half space is above ASCII, string(c) would take it as a rune.
+		switch d.data[start : start+1] {
+		case ControlEnd, PacketEndOfText:
+			return screen, nil
+		case ControlFrame:
+			d.pos++
+			frame = screen.AddFrame()
+			continue
+		case ControlLineFeed:
+			d.pos++
+			frame.LineFeed()
+			continue
+		case ControlHalfSpace:
+			d.pos++
+			frame.HalfSpace()
+			continue
+		}
+
+		if c >= 0x20 {
+			for d.pos < len(d.data) && d.data[d.pos] >= 0x20 && d.data[d.pos:d.pos+1] != ControlHalfSpace {
+				d.pos++
+			}
+			frame.Text(d.data[start:d.pos])
+			continue
+		}
+
+		segment, err := d.decodeControl()
+		if err != nil {
+			return nil, err
+		}
+		if err := segment.encode(&strings.Builder{}); err != nil {
+			return nil, &DecodeError{Offset: start, Reason: err.Error()}
+		}
+		frame.add(segment)
+	}
+	return nil, d.errorf(0, "missing end of transmission")
+}
+
+func (d *decoder) decodeControl() (Segment, error) {
+	control, _ := d.take(1, "control")
+	switch control {
+	case ControlFlash:
+		arg, err := d.take(1, "flash state")
+		if err != nil {
+			return nil, err
+		}
+		switch arg {
+		case FlashOn:
+			return FlashSegment{On: true}, nil
+		case FlashOff:
+			return FlashSegment{On: false}, nil
+		}
+		return nil, d.errorf(-1, "unknown flash state %q", arg)
+	case ControlPatternIn[:1]:
+		direction, err := d.take(2, "pattern direction")
+		if err != nil {
+			return nil, err
+		}
+		pattern, err := d.take(1, "pattern")
+		if err != nil {
+			return nil, err
+		}
+		switch control + direction {
+		case ControlPatternIn:
+			return PatternInSegment{Pattern(pattern)}, nil
+		case ControlPatternOut:
+			return PatternOutSegment{Pattern(pattern)}, nil
+		}
+		return nil, d.errorf(-3, "unknown pattern direction %q", direction)
+	case ControlSpecial:
+		arg, err := d.take(1, "special")
+		return SpecialSegment{Special(arg)}, err
+	case ControlSpeed:
+		arg, err := d.take(1, "speed")
+		return SpeedSegment{Speed(arg)}, err
+	case ControlFontColor:
+		arg, err := d.take(1, "font color")
+		return ColorSegment{FontColor(arg)}, err
+	case ControlBackgroundColor:
+		arg, err := d.take(1, "background color")
+		return BackgroundSegment{BackgroundColor(arg)}, err
+	case ControlAlignHorizontal:
+		arg, err := d.take(1, "horizontal alignment")
+		return HorizontalAlignSegment{HorizontalAlign(arg)}, err
+	case ControlAlignVertical:
+		arg, err := d.take(1, "vertical alignment")
+		return VerticalAlignSegment{VerticalAlign(arg)}, err
+	case ControlFont:
+		arg, err := d.take(1, "font")
+		return FontSegment{Font(control + arg)}, err
+	case ControlPause:
+		return d.decodePause(control)
+	case ControlPicture:
+		arg, err := d.take(1, "picture label")
+		return PictureSegment{Label: arg}, err
+	case ControlString:
+		arg, err := d.take(1, "string file label")
+		return VariableSegment{Label: arg}, err
+	}
+	return nil, d.errorf(-1, "unknown control byte %q", control)
+}
+
+func (d *decoder) decodePause(control string) (Segment, error) {
+	unit, err := d.take(1, "pause unit")
+	if err != nil {
+		return nil, err
+	}
+
+	var segment PauseSegment
+	switch control + unit {
+	case PauseSecond2:
+	case PauseMillisecond2:
+		segment.Milliseconds = true
+	case PauseSecond4:
+		segment.Wide = true
+	case PauseMillisecond4:
+		segment.Milliseconds, segment.Wide = true, true
+	default:
+		return nil, d.errorf(-1, "unknown pause unit %q", unit)
+	}
+
+	count := 2
+	if segment.Wide {
+		count = 4
+	}
+	digits, err := d.take(count, "pause digits")
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return nil, d.errorf(i-count, "pause digit %q is not a decimal digit", digits[i])
+		}
+		segment.Value = segment.Value*10 + int(digits[i]-'0')
+	}
+	return segment, nil
+}
+
+func (d *decoder) decodeFileAllocation() (FileAllocation, error) {
+	entry, err := d.take(fileAllocationSize, "file allocation")
+	if err != nil {
+		return FileAllocation{}, err
+	}
+
+	allocation := FileAllocation{Label: entry[0:1], Type: entry[1:2]}
+	switch entry[2:3] {
+	case fileLocked:
+		allocation.Locked = true
+	case fileUnlocked:
+	default:
+		return FileAllocation{}, d.errorf(2-fileAllocationSize, "unknown lock state %q", entry[2])
+	}
+	if allocation.Type == FileTypePicture {
+		if _, err := fmt.Sscanf(entry[3:7], "%02X%02X", &allocation.Height, &allocation.Width); err != nil {
+			return FileAllocation{}, d.errorf(3-fileAllocationSize, "invalid picture size %q", entry[3:7])
+		}
+		return allocation, nil
+	}
+	if _, err := fmt.Sscanf(entry[3:7], "%04X", &allocation.Size); err != nil {
+		return FileAllocation{}, d.errorf(3-fileAllocationSize, "invalid file size %q", entry[3:7])
+	}
+	return allocation, nil
+}
+
+func (d *decoder) decodeFont(packet *Packet) error {
+	code, err := d.take(1, "font slot")
+	if err != nil {
+		return err
+	}
+	packet.FontSlot = Font(ControlFont + code)
+	if !slices.Contains(customFonts, packet.FontSlot) {
+		return d.errorf(-1, "font %s is not a custom font slot", packet.FontSlot)
+	}
+	height, err := d.hex(2, "font height")
+	if err != nil {
+		return err
+	}
+	if height <= 0 || height > maxFontHeight {
+		return d.errorf(-2, "invalid font height %d", height)
+	}
+
+	packet.Font = &CustomFont{Height: height, Glyphs: make(map[byte]pixelfont.Glyph)}
+	for !d.atBodyEnd() {
+		code := d.data[d.pos]
+		d.pos++
+		width, err := d.hex(2, "glyph width")
+		if err != nil {
+			return err
+		}
+		if width > pixelfont.MaxWidth {
+			return d.errorf(-2, "glyph %q is %d pixels wide", code, width)
+		}
+
+		glyph := pixelfont.Glyph{Width: width, Rows: make([]uint32, height)}
+		digits := (width + 3) / 4
+		for y := range glyph.Rows {
+			row, err := d.hex(digits, "glyph row")
+			if err != nil {
+				return err
+			}
+			for x := 0; x < width; x++ {
+				if row&(1<<(digits*4-1-x)) != 0 {
+					glyph.Rows[y] |= 1 << x
+				}
+			}
+		}
+		packet.Font.Glyphs[code] = glyph
+	}
+	return nil
+}
+
+// hex reads an unsigned number of n hex digits.
+func (d *decoder) hex(n int, what string) (int, error) {
+	if n == 0 {
+		return 0, nil
+	}
+	digits, err := d.take(n, what)
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil {
+		return 0, d.errorf(-n, "invalid %s %q", what, digits)
+	}
+	return int(value), nil
+}
+
+// bcd reads a single byte holding two binary coded decimal digits.
+func (d *decoder) bcd() (int, error) {
+	b, err := d.take(1, "bcd value")
+	if err != nil {
+		return 0, err
+	}
+	high, low := b[0]>>4, b[0]&0x0f
+	if high > 9 || low > 9 {
+		return 0, d.errorf(-1, "byte %q is not binary coded decimal", b)
+	}
+	return int(high)*10 + int(low), nil
+}
+
+func (d *decoder) take(n int, what string) (string, error) {
+	if d.pos+n > len(d.data) {
+		return "", d.errorf(0, "datagram ends while reading %s", what)
+	}
+	value := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return value, nil
+}
+
+func (d *decoder) expect(value, what string) error {
+	if !strings.HasPrefix(d.data[d.pos:], value) {
+		if d.pos >= len(d.data) {
+			return d.errorf(0, "datagram ends, expected %s", what)
+		}
+		return d.errorf(0, "expected %s %q, got %q", what, value, d.data[d.pos])
+	}
+	d.pos += len(value)
+	return nil
+}
+
+// errorf creates a DecodeError at the current position, moved by offset bytes.
+func (d *decoder) errorf(offset int, format string, args ...any) error {
+	return &DecodeError{Offset: d.pos + offset, Reason: fmt.Sprintf(format, args...)}
+}
+
+// String renders the packet as an indented, human readable tree.
+func (p *Packet) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "packet type=%q address=%q command=%q", p.Type, p.Address, p.Command)
+	if p.Checksum {
+		sb.WriteString(" checksum")
+	}
+	sb.WriteString("\n")
+
+	switch p.Command {
+	case CommandWriteText:
+		fmt.Fprintf(&sb, "  write text label=%q storage=%q\n", p.Label, p.Storage)
+		sb.WriteString(p.Screen.String())
+	case CommandSpecialFunction:
+		switch p.Function {
+		case FunctionDate:
+			fmt.Fprintf(&sb, "  set date %s weekday=%d\n", p.Date.Format(time.DateTime), p.Weekday)
+		case FunctionMemory:
+			sb.WriteString("  memory configuration\n")
+			for _, allocation := range p.Memory {
+				fmt.Fprintf(&sb, "    %s\n", allocation)
+			}
+		case FunctionRunSequence:
+			fmt.Fprintf(&sb, "  run sequence %q\n", p.Sequence)
+		case FunctionFont:
+			fmt.Fprintf(&sb, "  custom font %s height=%d glyphs=%d\n", p.FontSlot, p.Font.Height, len(p.Font.Glyphs))
+		case FunctionBrightness:
+			fmt.Fprintf(&sb, "  brightness %d%%\n", p.Brightness)
+		case FunctionSoftReset:
+			sb.WriteString("  soft reset\n")
+		case FunctionBeep:
+			fmt.Fprintf(&sb, "  beep frequency=%d duration=%s repeats=%d\n", p.Beep.Frequency, p.Beep.Duration, p.Beep.Repeats)
+		case FunctionConfig:
+			fmt.Fprintf(&sb, "  configuration speaker=%t clock24=%t\n", p.Config.Speaker, p.Config.Clock24)
+		case FunctionTestPattern:
+			fmt.Fprintf(&sb, "  test pattern on=%t\n", p.TestPattern)
+		}
+	case CommandWritePicture:
+		fmt.Fprintf(&sb, "  write picture label=%q size=%dx%d\n", p.Label, p.Picture.Width, p.Picture.Height)
+	case CommandWriteString:
+		fmt.Fprintf(&sb, "  write string label=%q value=%q\n", p.Label, p.Value)
+	case CommandReadText:
+		fmt.Fprintf(&sb, "  read text label=%q\n", p.Label)
+	case CommandReadString:
+		fmt.Fprintf(&sb, "  read string label=%q\n", p.Label)
+	case CommandReadFunction:
+		fmt.Fprintf(&sb, "  read special function %q\n", p.Function)
+	}
+	return sb.String()
+}
+
+// String renders the screen as an indented, human readable tree.
+func (s *Screen) String() string {
+	var sb strings.Builder
+	for i, frame := range s.Frames {
+		fmt.Fprintf(&sb, "  frame %d\n", i)
+		for _, segment := range frame.Segments {
+			fmt.Fprintf(&sb, "    %s\n", segment)
+		}
+	}
+	return sb.String()
+}
+
+func (s TextSegment) String() string            { return fmt.Sprintf("text %q", s.Text) }
+func (s FontSegment) String() string            { return "font " + s.Font.String() }
+func (s ColorSegment) String() string           { return "color " + s.Color.String() }
+func (s BackgroundSegment) String() string      { return "background " + s.Color.String() }
+func (s PatternInSegment) String() string       { return "pattern-in " + s.Pattern.String() }
+func (s PatternOutSegment) String() string      { return "pattern-out " + s.Pattern.String() }
+func (s SpeedSegment) String() string           { return "speed " + s.Speed.String() }
+func (s HorizontalAlignSegment) String() string { return "align-horizontal " + s.Align.String() }
+func (s VerticalAlignSegment) String() string   { return "align-vertical " + s.Align.String() }
+func (s SpecialSegment) String() string         { return "special " + s.Special.String() }
+func (s LineFeedSegment) String() string        { return "line-feed" }
+func (s HalfSpaceSegment) String() string       { return "half-space" }
+func (s PictureSegment) String() string         { return fmt.Sprintf("picture %q", s.Label) }
+func (s VariableSegment) String() string        { return fmt.Sprintf("variable %q", s.Label) }
+
+func (s FlashSegment) String() string {
+	if s.On {
+		return "flash on"
+	}
+	return "flash off"
+}
+
+func (s PauseSegment) String() string {
+	unit := "s"
+	if s.Milliseconds {
+		unit = "ms"
+	}
+	if s.Wide {
+		return fmt.Sprintf("pause %04d%s", s.Value, unit)
+	}
+	return fmt.Sprintf("pause %02d%s", s.Value, unit)
+}
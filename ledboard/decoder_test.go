@@ -0,0 +1,119 @@
+package ledboard_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+	"github.com/b4ckspace/ledboard-v2/screens"
+)
+
+// signs frame packets without and with a checksum.
+var signs = map[string]ledboard.Sign{
+	"plain":    {Type: ledboard.PacketTypeAll, Address: ledboard.PacketAddressAll},
+	"checksum": {Type: ledboard.PacketTypeAll, Address: ledboard.PacketAddressAll, Checksum: true},
+}
+
+func TestDecodeScreens(t *testing.T) {
+	s := screens.NewScreens(screens.DefaultBoard, nil)
+	tests := map[string]*ledboard.Screen{
+		"alarm":           s.Alarm("Fire in the hole"),
+		"donation":        s.Donation(),
+		"doorbell":        s.DoorBell(),
+		"idle":            s.Idle(),
+		"laser-finished":  s.LaserFinished(3*3600 + 25*60 + 7),
+		"laser-operation": s.LaserOperation(),
+		"new-member":      s.NewMemberRegistration("Grüße {an} alle"),
+		"now-playing":     s.NowPlaying("Daft Punk - Around the World"),
+		"pizza":           s.PizzaTimer(),
+		"psa":             s.PublicServiceAnnouncement(strings.Repeat("A rather long announcement ", 8)),
+		"half-space":      halfSpaced(),
+	}
+
+	for name, screen := range tests {
+		encoded, err := screen.Encode()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for framing, sign := range signs {
+			t.Run(name+"/"+framing, func(t *testing.T) {
+				packet, err := ledboard.Decode(sign.Packet(ledboard.TextFile("A", ledboard.StorageFlash, encoded)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if packet.Checksum != sign.Checksum {
+					t.Errorf("checksum = %v, want %v", packet.Checksum, sign.Checksum)
+				}
+				if packet.Command != ledboard.CommandWriteText || packet.Label != "A" || packet.Storage != ledboard.StorageFlash {
+					t.Errorf("decoded command %q label %q storage %q", packet.Command, packet.Label, packet.Storage)
+				}
+				if !reflect.DeepEqual(packet.Screen, screen) {
+					t.Errorf("decoded screen differs\n got: %s\nwant: %s", dump(packet.Screen), dump(screen))
+				}
+			})
+		}
+	}
+}
+
+// halfSpaced returns a screen with half spaces between and after text.
+func halfSpaced() *ledboard.Screen {
+	screen := ledboard.NewScreen()
+	screen.AddFrame().Font(ledboard.FontNormal7x6).Text("12").HalfSpace().Text("34").HalfSpace().PauseSeconds(2)
+	return screen
+}
+
+func TestDecodeDate(t *testing.T) {
+	// The BCD bytes of the 3rd and 4th equal end of text and end of
+	// transmission, framing is told by the packet's structure.
+	tests := []time.Time{
+		time.Date(2026, 4, 3, 4, 3, 4, 0, time.UTC),
+		time.Date(2026, 3, 4, 3, 4, 3, 0, time.UTC),
+		time.Date(2031, 12, 31, 23, 59, 59, 0, time.UTC),
+	}
+	for _, date := range tests {
+		for framing, sign := range signs {
+			t.Run(date.Format(time.DateTime)+"/"+framing, func(t *testing.T) {
+				packet, err := ledboard.Decode(sign.Packet(ledboard.DateFunction(date)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if packet.Checksum != sign.Checksum {
+					t.Errorf("checksum = %v, want %v", packet.Checksum, sign.Checksum)
+				}
+				if packet.Function != ledboard.FunctionDate || !packet.Date.Equal(date) {
+					t.Errorf("decoded function %q date %s, want %s", packet.Function, packet.Date, date)
+				}
+				if want := ledboard.SignWeekday(date.Weekday()); packet.Weekday != want {
+					t.Errorf("weekday = %d, want %d", packet.Weekday, want)
+				}
+			})
+		}
+	}
+}
+
+func TestDecodeChecksumMismatch(t *testing.T) {
+	datagram := signs["checksum"].Packet(ledboard.DateFunction(time.Date(2026, 4, 3, 4, 3, 4, 0, time.UTC)))
+	corrupted := []byte(datagram)
+	corrupted[len(corrupted)-2]++
+	if _, err := ledboard.Decode(string(corrupted)); err == nil {
+		t.Fatal("corrupted checksum accepted")
+	}
+}
+
+// dump describes the segments of a screen.
+func dump(screen *ledboard.Screen) string {
+	if screen == nil {
+		return "<nil>"
+	}
+	var sb strings.Builder
+	for i, frame := range screen.Frames {
+		fmt.Fprintf(&sb, "\n  frame %d", i)
+		for _, segment := range frame.Segments {
+			fmt.Fprintf(&sb, "\n    %v", segment)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,7 @@
+package ledboard
+
+// Packet frames a command for the sign, for the tests of package
+// ledboard_test.
+func (s Sign) Packet(command string) string {
+	return s.packet(command)
+}
@@ -0,0 +1,158 @@
+package ledboard
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/b4ckspace/ledboard-v2/pixelfont"
+)
+
+const (
+	// firstExtraCode is the character code of the first glyph outside of
+	// printable ASCII in a custom font, lower codes are control bytes.
+	firstExtraCode = 0xA0
+
+	// maxFontHeight is the tallest custom font the sign accepts.
+	maxFontHeight = 32
+)
+
+// customFonts are the font slots that can be filled by an upload.
+var customFonts = []Font{FontCustom3, FontCustom4, FontCustom5, FontCustom6, FontCustom7, FontCustom8, FontCustom9}
+
+// CustomFont is a font as stored in one of the sign's custom font slots, its
+// glyphs indexed by the character code used in text.
+type CustomFont struct {
+	Height int
+	Glyphs map[byte]pixelfont.Glyph
+}
+
+// fontSlot is a font assigned to a custom font slot.
+type fontSlot struct {
+	name  string
+	font  *CustomFont
+	codes map[rune]byte
+}
+
+// FontSet manages which font lives in which custom font slot and maps text
+// to the character codes of the uploaded glyphs.
+type FontSet struct {
+	slots map[Font]*fontSlot
+}
+
+// NewFontSet creates an empty set, all slots keep the sign's fonts.
+func NewFontSet() *FontSet {
+	return &FontSet{slots: make(map[Font]*fontSlot)}
+}
+
+// Assign puts the font into a custom font slot. Printable ASCII characters
+// keep their codes, all other characters get codes from 0xA0 on.
+func (s *FontSet) Assign(slot Font, font *pixelfont.Font) error {
+	if !slices.Contains(customFonts, slot) {
+		return fmt.Errorf("font %s is not a custom font slot", slot)
+	}
+	if font.Height <= 0 || font.Height > maxFontHeight {
+		return fmt.Errorf("font %q is %d pixels high, at most %d are supported", font.Name, font.Height, maxFontHeight)
+	}
+
+	custom := &CustomFont{Height: font.Height, Glyphs: make(map[byte]pixelfont.Glyph)}
+	codes := make(map[rune]byte)
+	next := firstExtraCode
+	for _, c := range slices.Sorted(maps.Keys(font.Glyphs)) {
+		var code byte
+		switch {
+		case c >= ' ' && c <= '~':
+			code = byte(c)
+		case c < ' ' || c == 0x7F:
+			continue
+		case next > 0xFF:
+			return fmt.Errorf("font %q has more than %d non-ASCII glyphs", font.Name, 0x100-firstExtraCode)
+		default:
+			code = byte(next)
+			next++
+		}
+		custom.Glyphs[code] = font.Glyphs[c]
+		codes[c] = code
+	}
+
+	s.slots[slot] = &fontSlot{name: font.Name, font: custom, codes: codes}
+	return nil
+}
+
+// Slot returns the slot holding the font with the given name, the first
+// of the slots if several do.
+func (s *FontSet) Slot(name string) (Font, bool) {
+	for _, slot := range slices.Sorted(maps.Keys(s.slots)) {
+		if s.slots[slot].name == name {
+			return slot, true
+		}
+	}
+	return "", false
+}
+
+// Has reports whether the slot's font has a glyph for c.
+func (s *FontSet) Has(slot Font, c rune) bool {
+	f, ok := s.slots[slot]
+	if !ok {
+		return false
+	}
+	_, ok = f.codes[c]
+	return ok
+}
+
+// Text translates text into the character codes of the font in the slot, to
+// be used with Frame.Text after selecting the slot with Frame.Font. Characters
+// the font has no glyph for are replaced by a question mark.
+func (s *FontSet) Text(slot Font, text string) string {
+	f, ok := s.slots[slot]
+	if !ok {
+		return text
+	}
+
+	var sb strings.Builder
+	for _, c := range text {
+		code, ok := f.codes[c]
+		if !ok {
+			code = '?'
+		}
+		sb.WriteByte(code)
+	}
+	return sb.String()
+}
+
+// Fonts returns the assigned fonts by slot, as they are stored on the sign.
+func (s *FontSet) Fonts() map[Font]*CustomFont {
+	fonts := make(map[Font]*CustomFont, len(s.slots))
+	for slot, f := range s.slots {
+		fonts[slot] = f.font
+	}
+	return fonts
+}
+
+// FontFunction encodes the special function uploading a custom font: the
+// slot's font code, the height and every glyph as its character code, its
+// width and one row of hex digits per pixel row, the leftmost pixel being
+// the highest bit.
+func FontFunction(slot Font, font *CustomFont) string {
+	var sb strings.Builder
+	sb.WriteString(CommandSpecialFunction + FunctionFont)
+	sb.WriteString(string(slot[len(ControlFont):]))
+	sb.WriteString(fmt.Sprintf("%02X", font.Height))
+	for _, code := range slices.Sorted(maps.Keys(font.Glyphs)) {
+		glyph := font.Glyphs[code]
+		sb.WriteByte(code)
+		sb.WriteString(fmt.Sprintf("%02X", glyph.Width))
+		digits := (glyph.Width + 3) / 4
+		for y := 0; y < font.Height; y++ {
+			var row uint64
+			for x := 0; x < glyph.Width; x++ {
+				if glyph.Pixel(x, y) {
+					row |= 1 << (digits*4 - 1 - x)
+				}
+			}
+			sb.WriteString(fmt.Sprintf("%0*X", digits, row))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,28 @@
+package ledboard_test
+
+import (
+	"testing"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+	"github.com/b4ckspace/ledboard-v2/pixelfont"
+)
+
+func TestFontSetSlot(t *testing.T) {
+	font := pixelfont.Compact()
+	// Map order differs between runs and sets, the slot mustn't.
+	for range 50 {
+		fonts := ledboard.NewFontSet()
+		for _, slot := range []ledboard.Font{ledboard.FontCustom7, ledboard.FontCustom4, ledboard.FontCustom5} {
+			if err := fonts.Assign(slot, font); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if slot, ok := fonts.Slot(font.Name); !ok || slot != ledboard.FontCustom4 {
+			t.Fatalf("Slot(%q) = %s, %v, want %s", font.Name, slot, ok, ledboard.FontCustom4)
+		}
+	}
+
+	if _, ok := ledboard.NewFontSet().Slot(font.Name); ok {
+		t.Error("empty set has a slot")
+	}
+}
@@ -0,0 +1,157 @@
+package ledboard
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// beepProgrammable selects a tone of the given frequency, duration and
+	// repeats, rather than one of the fixed beeps.
+	beepProgrammable = "2"
+
+	speakerOn  = "00"
+	speakerOff = "FF"
+
+	timeFormat24 = "M"
+	timeFormat12 = "S"
+
+	// MaxBeepFrequency is the highest tone of the speaker.
+	MaxBeepFrequency = 0xFE
+	// MaxBeepDuration is the longest single beep, in steps of a tenth of a second.
+	MaxBeepDuration = 15 * 100 * time.Millisecond
+	// MaxBeepRepeats is how often a beep can be repeated.
+	MaxBeepRepeats = 0xF
+)
+
+// Beep is a tone of the sign's speaker.
+type Beep struct {
+	// Frequency is the pitch from 0 to MaxBeepFrequency, higher is higher.
+	Frequency int
+	// Duration is rounded to a tenth of a second, at least one.
+	Duration time.Duration
+	// Repeats is how often the beep is repeated after the first.
+	Repeats int
+}
+
+// ParseBeep parses a beep of the form "frequency,duration,repeats", e.g.
+// "120,300ms,2".
+func ParseBeep(spec string) (Beep, error) {
+	fields := strings.Split(spec, ",")
+	if len(fields) != 3 {
+		return Beep{}, fmt.Errorf("beep %q: expected frequency,duration,repeats", spec)
+	}
+	frequency, err := strconv.Atoi(fields[0])
+	if err != nil || frequency < 0 || frequency > MaxBeepFrequency {
+		return Beep{}, fmt.Errorf("beep %q: frequency must be 0 to %d", spec, MaxBeepFrequency)
+	}
+	duration, err := time.ParseDuration(fields[1])
+	if err != nil || duration <= 0 || duration > MaxBeepDuration {
+		return Beep{}, fmt.Errorf("beep %q: duration must be up to %s", spec, MaxBeepDuration)
+	}
+	repeats, err := strconv.Atoi(fields[2])
+	if err != nil || repeats < 0 || repeats > MaxBeepRepeats {
+		return Beep{}, fmt.Errorf("beep %q: repeats must be 0 to %d", spec, MaxBeepRepeats)
+	}
+	return Beep{Frequency: frequency, Duration: duration, Repeats: repeats}, nil
+}
+
+func (b Beep) String() string {
+	return fmt.Sprintf("%d,%s,%d", b.Frequency, b.Duration, b.Repeats)
+}
+
+// BeepFunction encodes the special function sounding the beep, values out of
+// range are clamped.
+func BeepFunction(beep Beep) string {
+	frequency := min(max(beep.Frequency, 0), MaxBeepFrequency)
+	tenths := min(max(int((beep.Duration+50*time.Millisecond)/(100*time.Millisecond)), 1), 0xF)
+	repeats := min(max(beep.Repeats, 0), MaxBeepRepeats)
+	return CommandSpecialFunction + FunctionBeep + beepProgrammable + fmt.Sprintf("%02X%X%X", frequency, tenths, repeats)
+}
+
+// SignConfig holds the settings of the sign itself.
+type SignConfig struct {
+	// Speaker enables beeping.
+	Speaker bool
+	// Clock24 shows times of day in 24 hour format rather than with AM/PM.
+	Clock24 bool
+}
+
+// ConfigFunction encodes the special function holding the sign's settings.
+func ConfigFunction(config SignConfig) string {
+	cmd := CommandSpecialFunction + FunctionConfig
+	if config.Speaker {
+		cmd += speakerOn
+	} else {
+		cmd += speakerOff
+	}
+	if config.Clock24 {
+		cmd += timeFormat24
+	} else {
+		cmd += timeFormat12
+	}
+	return cmd
+}
+
+// TestPatternFunction encodes the special function starting or stopping the
+// sign's built-in test pattern, which lights every LED in every color.
+func TestPatternFunction(on bool) string {
+	if on {
+		return CommandSpecialFunction + FunctionTestPattern + FlashOn
+	}
+	return CommandSpecialFunction + FunctionTestPattern + FlashOff
+}
+
+// SoftReset restarts the LED board, like a power cycle it loses the files
+// stored in RAM.
+func (c *Client) SoftReset() error {
+	slog.Info("resetting LED board")
+	return c.enqueue("", func(t *target) string {
+		return t.sign.packet(CommandSpecialFunction + FunctionSoftReset)
+	})
+}
+
+// ClearMemory erases all files and the memory configuration of the LED
+// board, an empty memory configuration.
+func (c *Client) ClearMemory() error {
+	slog.Info("clearing LED board memory")
+	return c.enqueue("memory", func(t *target) string {
+		return t.sign.packet(MemoryFunction(nil))
+	})
+}
+
+// Beep sounds the LED board's speaker, if it is enabled in its SignConfig.
+func (c *Client) Beep(beep Beep) error {
+	slog.Info("beeping", "beep", beep)
+	return c.enqueue("", func(t *target) string {
+		return t.sign.packet(BeepFunction(beep))
+	})
+}
+
+// SetConfig changes the settings of the LED board.
+func (c *Client) SetConfig(config SignConfig) error {
+	slog.Info("configuring LED board", "speaker", config.Speaker, "clock24", config.Clock24)
+	return c.enqueue("config", func(t *target) string {
+		return t.sign.packet(ConfigFunction(config))
+	})
+}
+
+// ReadConfig reads the settings of the LED board, found in Result.Packet.Config.
+func (c *Client) ReadConfig(ctx context.Context, policy Policy) ([]Result, error) {
+	return c.requestAll(ctx, func(t *target) string {
+		return t.sign.packet(CommandReadFunction + FunctionConfig)
+	}, policy)
+}
+
+// ShowTestPattern starts or stops the LED board's test pattern. Writing a
+// file or run sequence stops it as well.
+func (c *Client) ShowTestPattern(on bool) error {
+	slog.Info("switching test pattern", "on", on)
+	return c.enqueue("test", func(t *target) string {
+		return t.sign.packet(TestPatternFunction(on))
+	})
+}
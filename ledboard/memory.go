@@ -0,0 +1,72 @@
+package ledboard
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// DefaultFile is the text file that exists without any memory configuration.
+	DefaultFile = "A"
+
+	// File types of the memory configuration
+	FileTypeText    = "A"
+	FileTypeString  = "B"
+	FileTypePicture = "D"
+
+	fileLocked   = "L"
+	fileUnlocked = "U"
+
+	// pictureTriColor marks picture files using red, green and amber.
+	pictureTriColor = "2000"
+
+	// fileAllocationSize is the length of a single memory configuration entry.
+	fileAllocationSize = 11
+)
+
+// FileAllocation describes a file in the sign's memory configuration.
+type FileAllocation struct {
+	Label  string
+	Type   string
+	Locked bool
+
+	// Size in bytes of text and string files.
+	Size int
+	// Width and Height in pixels of picture files.
+	Width  int
+	Height int
+}
+
+func (a FileAllocation) encode() string {
+	lock := fileUnlocked
+	if a.Locked {
+		lock = fileLocked
+	}
+
+	switch a.Type {
+	case FileTypeText:
+		// Text files are always shown.
+		return fmt.Sprintf("%s%s%s%04XFF00", a.Label, a.Type, lock, a.Size)
+	case FileTypePicture:
+		// Pictures store their size in pixels and use three colors.
+		return fmt.Sprintf("%s%s%s%02X%02X%s", a.Label, a.Type, lock, a.Height, a.Width, pictureTriColor)
+	}
+	return fmt.Sprintf("%s%s%s%04X0000", a.Label, a.Type, lock, a.Size)
+}
+
+func (a FileAllocation) String() string {
+	lock := "unlocked"
+	if a.Locked {
+		lock = "locked"
+	}
+	if a.Type == FileTypePicture {
+		return fmt.Sprintf("file %q type=%q %s size=%dx%d", a.Label, a.Type, lock, a.Width, a.Height)
+	}
+	return fmt.Sprintf("file %q type=%q %s size=%d", a.Label, a.Type, lock, a.Size)
+}
+
+// RunSequenceFunction encodes the special function selecting the text files
+// the sign shows, in order.
+func RunSequenceFunction(labels ...string) string {
+	return CommandSpecialFunction + FunctionRunSequence + RunSequenceInOrder + strings.Join(labels, "")
+}
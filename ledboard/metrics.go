@@ -0,0 +1,84 @@
+package ledboard
+
+// FontMetrics describes the character cells of a font in pixels.
+type FontMetrics struct {
+	// Height of a line of text. Descenders may reach below it.
+	Height int
+	// Width is the advance of a character, including the spacing to the next.
+	Width int
+	// Bold fonts draw every stroke two pixels wide.
+	Bold bool
+
+	// widths holds the advance per character code of proportional fonts.
+	widths map[byte]int
+}
+
+// builtinMetrics holds the cell sizes of the sign's builtin fonts, which are
+// monospaced.
+var builtinMetrics = map[Font]FontMetrics{
+	FontNormal5x5:   {Height: 5, Width: 5},
+	FontNormal7x6:   {Height: 7, Width: 6},
+	FontNormal14x8:  {Height: 14, Width: 8},
+	FontNormal11x9:  {Height: 11, Width: 9},
+	FontNormal15x9:  {Height: 15, Width: 9},
+	FontNormal16x9:  {Height: 16, Width: 9},
+	FontNormal24x16: {Height: 24, Width: 16},
+	FontNormal22x18: {Height: 22, Width: 18},
+	FontNormal30x18: {Height: 30, Width: 18},
+	FontNormal32x18: {Height: 32, Width: 18},
+	FontNormal40x21: {Height: 40, Width: 21},
+	FontBold5x7:     {Height: 5, Width: 7, Bold: true},
+	FontBold14x10:   {Height: 14, Width: 10, Bold: true},
+	FontBold15x10:   {Height: 15, Width: 10, Bold: true},
+	FontBold16x12:   {Height: 16, Width: 12, Bold: true},
+}
+
+// Metrics returns the metrics of a builtin font. Custom font slots have none,
+// their metrics depend on the uploaded font, see FontSet.Metrics.
+func (f Font) Metrics() (FontMetrics, bool) {
+	m, ok := builtinMetrics[f]
+	return m, ok
+}
+
+// Metrics returns the metrics of the uploaded font. Characters without a
+// glyph are as wide as the widest glyph.
+func (f *CustomFont) Metrics() FontMetrics {
+	m := FontMetrics{Height: f.Height, widths: make(map[byte]int, len(f.Glyphs))}
+	for code, glyph := range f.Glyphs {
+		m.widths[code] = glyph.Width
+		m.Width = max(m.Width, glyph.Width)
+	}
+	return m
+}
+
+// Metrics returns the metrics of the font in a custom font slot, or those of
+// the builtin font.
+func (s *FontSet) Metrics(font Font) (FontMetrics, bool) {
+	if slot, ok := s.slots[font]; ok {
+		return slot.font.Metrics(), true
+	}
+	return font.Metrics()
+}
+
+// CharWidth returns the advance of a character code.
+func (m FontMetrics) CharWidth(c byte) int {
+	if w, ok := m.widths[c]; ok {
+		return w
+	}
+	return m.Width
+}
+
+// TextWidth returns the width of text in pixels, text being in the sign's
+// character codes.
+func (m FontMetrics) TextWidth(text string) int {
+	width := 0
+	for i := 0; i < len(text); i++ {
+		width += m.CharWidth(text[i])
+	}
+	return width
+}
+
+// HalfSpaceWidth returns the width of a HalfSpaceSegment.
+func (m FontMetrics) HalfSpaceWidth() int {
+	return m.CharWidth(' ') / 2
+}
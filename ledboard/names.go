@@ -0,0 +1,209 @@
+package ledboard
+
+import "fmt"
+
+var fontNames = map[Font]string{
+	FontNormal5x5:   "normal-5x5",
+	FontNormal7x6:   "normal-7x6",
+	FontNormal14x8:  "normal-14x8",
+	FontNormal11x9:  "normal-11x9",
+	FontNormal15x9:  "normal-15x9",
+	FontNormal16x9:  "normal-16x9",
+	FontNormal24x16: "normal-24x16",
+	FontNormal22x18: "normal-22x18",
+	FontNormal30x18: "normal-30x18",
+	FontNormal32x18: "normal-32x18",
+	FontNormal40x21: "normal-40x21",
+	FontBold5x7:     "bold-5x7",
+	FontBold14x10:   "bold-14x10",
+	FontBold15x10:   "bold-15x10",
+	FontBold16x12:   "bold-16x12",
+	FontCustom3:     "custom-3",
+	FontCustom4:     "custom-4",
+	FontCustom5:     "custom-5",
+	FontCustom6:     "custom-6",
+	FontCustom7:     "custom-7",
+	FontCustom8:     "custom-8",
+	FontCustom9:     "custom-9",
+}
+
+var fontColorNames = map[FontColor]string{
+	FontColorBlack:         "black",
+	FontColorRed:           "red",
+	FontColorGreen:         "green",
+	FontColorYellow:        "yellow",
+	FontColorYGRCharacter:  "ygr-character",
+	FontColorYGRHorizontal: "ygr-horizontal",
+	FontColorYGRWave:       "ygr-wave",
+	FontColorYGRDiagonal:   "ygr-diagonal",
+}
+
+var backgroundColorNames = map[BackgroundColor]string{
+	BackgroundColorBlack:  "black",
+	BackgroundColorRed:    "red",
+	BackgroundColorGreen:  "green",
+	BackgroundColorYellow: "yellow",
+}
+
+var patternNames = map[Pattern]string{
+	PatternRandom:                  "random",
+	PatternJumpOut:                 "jump-out",
+	PatternMoveLeft:                "move-left",
+	PatternMoveRight:               "move-right",
+	PatternScrollLeft:              "scroll-left",
+	PatternScrollRight:             "scroll-right",
+	PatternMoveUp:                  "move-up",
+	PatternMoveDown:                "move-down",
+	PatternScrollLR:                "scroll-lr",
+	PatternScrollUp:                "scroll-up",
+	PatternScrollDown:              "scroll-down",
+	PatternFoldLR:                  "fold-lr",
+	PatternFoldUD:                  "fold-ud",
+	PatternScrollUD:                "scroll-ud",
+	PatternShuttleLR:               "shuttle-lr",
+	PatternShuttleUD:               "shuttle-ud",
+	PatternPeelOffL:                "peel-off-left",
+	PatternPeelOffR:                "peel-off-right",
+	PatternShutterUD:               "shutter-ud",
+	PatternShutterLR:               "shutter-lr",
+	PatternRaindrops:               "raindrops",
+	PatternRandomMosaic:            "random-mosaic",
+	PatternTwinklingStar:           "twinkling-star",
+	PatternHipHop:                  "hip-hop",
+	PatternRadarScan:               "radar-scan",
+	PatternFanOut:                  "fan-out",
+	PatternFanIn:                   "fan-in",
+	PatternSpiralR:                 "spiral-right",
+	PatternSpiralL:                 "spiral-left",
+	PatternToFourCorners:           "to-four-corners",
+	PatternFromFourCorners:         "from-four-corners",
+	PatternToFourSides:             "to-four-sides",
+	PatternFromFourSides:           "from-four-sides",
+	PatternScrollOutFromFourBlocks: "scroll-out-from-four-blocks",
+}
+
+var specialNames = map[Special]string{
+	SpecialMMDDYYSLA:   "mm/dd/yy",
+	SpecialDDMMYYSLA:   "dd/mm/yy",
+	SpecialMMDDYYDSH:   "mm-dd-yy",
+	SpecialDDMMYYDSH:   "dd-mm-yy",
+	SpecialMMDDYYYYDOT: "mm.dd.yyyy",
+	SpecialYY:          "yy",
+	SpecialYYYY:        "yyyy",
+	SpecialMM:          "mm",
+	SpecialMMM:         "mmm",
+	SpecialDD:          "dd",
+	SpecialDDOfWeek:    "day-of-week",
+	SpecialDDDOfWeek:   "day-of-week-name",
+	SpecialHH:          "hh",
+	SpecialMIN:         "min",
+	SpecialSEC:         "sec",
+	SpecialHHMin24:     "hh:min-24",
+	SpecialHHMin12:     "hh:min-12",
+}
+
+var speedNames = map[Speed]string{
+	SpeedVeryFast:   "very-fast",
+	SpeedFast:       "fast",
+	SpeedMediumFast: "medium-fast",
+	SpeedMedium:     "medium",
+	SpeedMediumSlow: "medium-slow",
+	SpeedSlow:       "slow",
+	SpeedVerySlow:   "very-slow",
+}
+
+var horizontalAlignNames = map[HorizontalAlign]string{
+	AlignHorizontalCenter:   "center",
+	AlignHorizontalLeft:     "left",
+	AlignHorizontalRight:    "right",
+	AlignHorizontalReserved: "reserved",
+}
+
+var verticalAlignNames = map[VerticalAlign]string{
+	AlignVerticalCenter:   "center",
+	AlignVerticalTop:      "top",
+	AlignVerticalBottom:   "bottom",
+	AlignVerticalReserved: "reserved",
+}
+
+func (f Font) String() string            { return name(fontNames, f) }
+func (c FontColor) String() string       { return name(fontColorNames, c) }
+func (c BackgroundColor) String() string { return name(backgroundColorNames, c) }
+func (p Pattern) String() string         { return name(patternNames, p) }
+func (s Special) String() string         { return name(specialNames, s) }
+func (s Speed) String() string           { return name(speedNames, s) }
+func (a HorizontalAlign) String() string { return name(horizontalAlignNames, a) }
+func (a VerticalAlign) String() string   { return name(verticalAlignNames, a) }
+
+// Valid reports whether f is a font the sign knows.
+func (f Font) Valid() bool { _, ok := fontNames[f]; return ok }
+
+// Valid reports whether c is a font color the sign knows.
+func (c FontColor) Valid() bool { _, ok := fontColorNames[c]; return ok }
+
+// Valid reports whether c is a background color the sign knows.
+func (c BackgroundColor) Valid() bool { _, ok := backgroundColorNames[c]; return ok }
+
+// Valid reports whether p is a pattern the sign knows.
+func (p Pattern) Valid() bool { _, ok := patternNames[p]; return ok }
+
+// Valid reports whether s is a special the sign knows.
+func (s Special) Valid() bool { _, ok := specialNames[s]; return ok }
+
+// Valid reports whether s is a speed the sign knows.
+func (s Speed) Valid() bool { _, ok := speedNames[s]; return ok }
+
+// Valid reports whether a is a horizontal alignment the sign knows.
+func (a HorizontalAlign) Valid() bool { _, ok := horizontalAlignNames[a]; return ok }
+
+// Valid reports whether a is a vertical alignment the sign knows.
+func (a VerticalAlign) Valid() bool { _, ok := verticalAlignNames[a]; return ok }
+
+// ParseFont looks up a font by its name, like "normal-7x6" or "custom-3".
+func ParseFont(name string) (Font, error) { return parse(fontNames, "font", name) }
+
+// ParseFontColor looks up a font color by its name, like "green".
+func ParseFontColor(name string) (FontColor, error) { return parse(fontColorNames, "font color", name) }
+
+// ParseBackgroundColor looks up a background color by its name, like "black".
+func ParseBackgroundColor(name string) (BackgroundColor, error) {
+	return parse(backgroundColorNames, "background color", name)
+}
+
+// ParsePattern looks up a pattern by its name, like "scroll-up".
+func ParsePattern(name string) (Pattern, error) { return parse(patternNames, "pattern", name) }
+
+// ParseSpecial looks up a special by its name, like "hh".
+func ParseSpecial(name string) (Special, error) { return parse(specialNames, "special", name) }
+
+// ParseSpeed looks up a speed by its name, like "fast".
+func ParseSpeed(name string) (Speed, error) { return parse(speedNames, "speed", name) }
+
+// ParseHorizontalAlign looks up a horizontal alignment by its name, like "left".
+func ParseHorizontalAlign(name string) (HorizontalAlign, error) {
+	return parse(horizontalAlignNames, "horizontal alignment", name)
+}
+
+// ParseVerticalAlign looks up a vertical alignment by its name, like "top".
+func ParseVerticalAlign(name string) (VerticalAlign, error) {
+	return parse(verticalAlignNames, "vertical alignment", name)
+}
+
+// parse looks up a command argument by its human readable name.
+func parse[T ~string](names map[T]string, kind, name string) (T, error) {
+	for value, n := range names {
+		if n == name {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("unknown %s %q", kind, name)
+}
+
+// name looks up the human readable name of a command argument, falling back
+// to the quoted raw bytes for unknown values.
+func name[T ~string](names map[T]string, value T) string {
+	if n, ok := names[value]; ok {
+		return n
+	}
+	return fmt.Sprintf("%q", string(value))
+}
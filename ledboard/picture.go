@@ -0,0 +1,74 @@
+package ledboard
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PixelColor is the color of a single picture pixel.
+type PixelColor byte
+
+// Picture pixel colors
+const (
+	PixelOff   PixelColor = '0'
+	PixelRed   PixelColor = '1'
+	PixelGreen PixelColor = '2'
+	PixelAmber PixelColor = '3'
+)
+
+// maxPictureSize is the widest and tallest picture the sign accepts.
+const maxPictureSize = 0xFF
+
+// Picture is a bitmap stored in a picture file of the sign.
+type Picture struct {
+	Width  int
+	Height int
+	Pixels []PixelColor
+}
+
+// NewPicture creates a picture with all pixels off.
+func NewPicture(width, height int) *Picture {
+	pixels := make([]PixelColor, width*height)
+	for i := range pixels {
+		pixels[i] = PixelOff
+	}
+	return &Picture{Width: width, Height: height, Pixels: pixels}
+}
+
+// At returns the color of the pixel at x, y.
+func (p *Picture) At(x, y int) PixelColor {
+	if x < 0 || x >= p.Width || y < 0 || y >= p.Height {
+		return PixelOff
+	}
+	return p.Pixels[y*p.Width+x]
+}
+
+// Set colors the pixel at x, y.
+func (p *Picture) Set(x, y int, c PixelColor) {
+	if x < 0 || x >= p.Width || y < 0 || y >= p.Height {
+		return
+	}
+	p.Pixels[y*p.Width+x] = c
+}
+
+// PictureFile encodes a picture the way it is written to the sign: the label,
+// height and width as hex and every row of pixels ended by a carriage return.
+func PictureFile(label string, picture *Picture) (string, error) {
+	if len(label) != 1 {
+		return "", fmt.Errorf("invalid picture label %q", label)
+	}
+	if picture.Width <= 0 || picture.Width > maxPictureSize || picture.Height <= 0 || picture.Height > maxPictureSize {
+		return "", fmt.Errorf("picture size %dx%d is outside of 1x1 to %dx%d", picture.Width, picture.Height, maxPictureSize, maxPictureSize)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(CommandWritePicture + label)
+	fmt.Fprintf(&sb, "%02X%02X", picture.Height, picture.Width)
+	for y := 0; y < picture.Height; y++ {
+		for x := 0; x < picture.Width; x++ {
+			sb.WriteByte(byte(picture.At(x, y)))
+		}
+		sb.WriteString(ControlLineFeed)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,185 @@
+package ledboard
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// QueueOptions controls the send queue of every sign.
+type QueueOptions struct {
+	// Gap is the minimum time between two packets to the same sign, which
+	// drops or half-applies packets sent back to back.
+	Gap time.Duration
+	// Size bounds the number of packets waiting to be sent to a sign.
+	Size int
+}
+
+// QueueStats counts what happened to the packets queued for a sign.
+type QueueStats struct {
+	Pending int
+
+	Sent uint64
+	// Coalesced counts packets replaced by a newer one before being sent.
+	Coalesced uint64
+	// Dropped counts packets rejected because the queue was full.
+	Dropped uint64
+}
+
+// queued is a datagram waiting to be sent.
+type queued struct {
+	// key identifies what the datagram changes on the sign, like a text file.
+	// A newer datagram with the same key supersedes it, unless it is empty.
+	key      string
+	datagram string
+	trigger  *Trigger
+	// sent is closed once the datagram was written or dropped.
+	sent chan struct{}
+	// answered is closed once the answer to a request arrived or timed out,
+	// nil for datagrams sent without waiting. Nothing else is sent to the
+	// sign meanwhile, so the answer can't be taken for another's.
+	answered chan struct{}
+}
+
+// sendQueue holds the datagrams for a single sign, in order.
+type sendQueue struct {
+	mu     sync.Mutex
+	items  []*queued
+	size   int
+	closed bool
+	stats  QueueStats
+
+	// wake has room for a single signal that items are waiting.
+	wake chan struct{}
+}
+
+func newSendQueue(size int) *sendQueue {
+	return &sendQueue{size: size, wake: make(chan struct{}, 1)}
+}
+
+// push appends the datagram. A queued datagram with the same key is removed,
+// the new one takes its turn at the end.
+func (q *sendQueue) push(key, datagram string, trigger *Trigger, answered chan struct{}) (*queued, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil, ErrClosed
+	}
+	if key != "" {
+		if i := slices.IndexFunc(q.items, func(item *queued) bool { return item.key == key }); i >= 0 {
+			close(q.items[i].sent)
+			q.items = slices.Delete(q.items, i, i+1)
+			q.stats.Coalesced++
+		}
+	}
+	if len(q.items) >= q.size {
+		q.stats.Dropped++
+		return nil, ErrQueueFull
+	}
+
+	item := &queued{key: key, datagram: datagram, trigger: trigger, sent: make(chan struct{}), answered: answered}
+	q.items = append(q.items, item)
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return item, nil
+}
+
+// pop removes the oldest datagram, waiting for one if the queue is empty. It
+// returns nil once the queue is closed.
+func (q *sendQueue) pop() *queued {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return nil
+		}
+		if len(q.items) > 0 {
+			item := q.items[0]
+			q.items = q.items[1:]
+			q.stats.Sent++
+			q.mu.Unlock()
+			return item
+		}
+		q.mu.Unlock()
+		<-q.wake
+	}
+}
+
+// close drops all waiting datagrams and stops pop.
+func (q *sendQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+	q.closed = true
+	for _, item := range q.items {
+		close(item.sent)
+	}
+	q.items = nil
+	close(q.wake)
+}
+
+func (q *sendQueue) snapshot() QueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := q.stats
+	stats.Pending = len(q.items)
+	return stats
+}
+
+// write sends the queued datagrams of the target, keeping the gap between
+// them, until the queue is closed.
+func (t *target) write(gap time.Duration) {
+	for {
+		item := t.queue.pop()
+		if item == nil {
+			return
+		}
+		if item.answered != nil {
+			// Forget answers to earlier fire and forget sends.
+			t.discardResponses()
+		}
+		t.send(item.datagram)
+		t.record(item)
+		close(item.sent)
+		if item.answered != nil {
+			<-item.answered
+		}
+		time.Sleep(gap)
+	}
+}
+
+// enqueue queues the datagram for the target, superseding any queued
+// datagram with the same key. A request passes the channel it closes once
+// it got its answer.
+func (t *target) enqueue(key, datagram string, trigger *Trigger, answered chan struct{}) (*queued, error) {
+	if t.sign.MaxPacket > 0 && len(datagram) > t.sign.MaxPacket {
+		return nil, fmt.Errorf("%w: %d bytes, the sign takes %d", ErrPacketTooLarge, len(datagram), t.sign.MaxPacket)
+	}
+	item, err := t.queue.push(key, datagram, trigger, answered)
+	if errors.Is(err, ErrQueueFull) {
+		slog.Warn("LED board queue is full, dropping packet", "sign", t.sign.Name, "key", key)
+	}
+	return item, err
+}
+
+// record writes the sent datagram to the capture file, if recording.
+func (t *target) record(item *queued) {
+	recorder := t.recorder.Load()
+	if recorder == nil {
+		return
+	}
+	rec := Record{Time: time.Now(), Sign: t.sign.Name, Datagram: item.datagram, Trigger: item.trigger}
+	if err := recorder.Record(rec); err != nil {
+		slog.Error("failed to record datagram", "sign", t.sign.Name, "error", err)
+	}
+}
@@ -0,0 +1,114 @@
+package ledboard
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSendQueue(t *testing.T) {
+	type push struct {
+		key, datagram string
+		err           error
+	}
+	tests := []struct {
+		name   string
+		size   int
+		pushes []push
+		want   []string
+		stats  QueueStats
+	}{
+		{
+			name:   "in order",
+			size:   4,
+			pushes: []push{{datagram: "a"}, {datagram: "b"}, {datagram: "c"}},
+			want:   []string{"a", "b", "c"},
+			stats:  QueueStats{Pending: 3},
+		},
+		{
+			name:   "coalesced by key",
+			size:   4,
+			pushes: []push{{"A", "a1", nil}, {"B", "b1", nil}, {"A", "a2", nil}},
+			want:   []string{"b1", "a2"},
+			stats:  QueueStats{Pending: 2, Coalesced: 1},
+		},
+		{
+			name:   "empty keys never coalesce",
+			size:   4,
+			pushes: []push{{"", "a", nil}, {"", "a", nil}},
+			want:   []string{"a", "a"},
+			stats:  QueueStats{Pending: 2},
+		},
+		{
+			name:   "dropped when full",
+			size:   2,
+			pushes: []push{{datagram: "a"}, {datagram: "b"}, {"", "c", ErrQueueFull}, {"", "d", ErrQueueFull}},
+			want:   []string{"a", "b"},
+			stats:  QueueStats{Pending: 2, Dropped: 2},
+		},
+		{
+			name:   "coalescing makes room",
+			size:   2,
+			pushes: []push{{"A", "a1", nil}, {"B", "b1", nil}, {"A", "a2", nil}, {"C", "c1", ErrQueueFull}},
+			want:   []string{"b1", "a2"},
+			stats:  QueueStats{Pending: 2, Coalesced: 1, Dropped: 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q := newSendQueue(test.size)
+			var superseded []*queued
+			for _, p := range test.pushes {
+				item, err := q.push(p.key, p.datagram, nil, nil)
+				if !errors.Is(err, p.err) {
+					t.Fatalf("push(%q, %q) = %v, want %v", p.key, p.datagram, err, p.err)
+				}
+				if item != nil {
+					superseded = append(superseded, item)
+				}
+			}
+			if stats := q.snapshot(); stats != test.stats {
+				t.Errorf("stats = %+v, want %+v", stats, test.stats)
+			}
+
+			var got []string
+			for range test.want {
+				item := q.pop()
+				got = append(got, item.datagram)
+				superseded = slices.DeleteFunc(superseded, func(s *queued) bool { return s == item })
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("popped %q, want %q", got, test.want)
+			}
+			// The coalesced datagrams count as sent for their waiters.
+			for _, item := range superseded {
+				select {
+				case <-item.sent:
+				default:
+					t.Errorf("superseded %q is not done", item.datagram)
+				}
+			}
+			if stats := q.snapshot(); stats.Sent != uint64(len(test.want)) || stats.Pending != 0 {
+				t.Errorf("stats after popping = %+v", stats)
+			}
+		})
+	}
+}
+
+func TestSendQueueClose(t *testing.T) {
+	q := newSendQueue(4)
+	item, _ := q.push("", "a", nil, nil)
+	q.close()
+	select {
+	case <-item.sent:
+	default:
+		t.Error("dropped datagram is not done")
+	}
+	if item := q.pop(); item != nil {
+		t.Errorf("popped %q from a closed queue", item.datagram)
+	}
+	if _, err := q.push("", "b", nil, nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("push to a closed queue = %v, want %v", err, ErrClosed)
+	}
+}
@@ -0,0 +1,116 @@
+package ledboard
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Trigger is the MQTT message that made the daemon send a datagram.
+type Trigger struct {
+	Topic   string `json:"topic"`
+	Payload string `json:"payload"`
+}
+
+// Record is a datagram sent to a sign, as written to a capture file.
+type Record struct {
+	Time     time.Time
+	Sign     string
+	Datagram string
+	Trigger  *Trigger
+}
+
+// record is the JSON form of a Record. Datagrams aren't UTF-8, every byte is
+// stored as the character of the same code, so the text stays readable and
+// nothing is lost.
+type record struct {
+	Time     time.Time `json:"time"`
+	Sign     string    `json:"sign"`
+	Datagram string    `json:"datagram"`
+	Trigger  *Trigger  `json:"trigger,omitempty"`
+}
+
+func (r Record) MarshalJSON() ([]byte, error) {
+	runes := make([]rune, len(r.Datagram))
+	for i := 0; i < len(r.Datagram); i++ {
+		runes[i] = rune(r.Datagram[i])
+	}
+	return json.Marshal(record{Time: r.Time, Sign: r.Sign, Datagram: string(runes), Trigger: r.Trigger})
+}
+
+func (r *Record) UnmarshalJSON(data []byte) error {
+	var rec record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return err
+	}
+
+	var datagram strings.Builder
+	for _, c := range rec.Datagram {
+		if c > 0xFF {
+			return fmt.Errorf("invalid datagram character %q", c)
+		}
+		datagram.WriteByte(byte(c))
+	}
+	*r = Record{Time: rec.Time, Sign: rec.Sign, Datagram: datagram.String(), Trigger: rec.Trigger}
+	return nil
+}
+
+// Recorder writes every datagram sent to a capture file, a JSON record per
+// line.
+type Recorder struct {
+	mu      sync.Mutex
+	w       io.WriteCloser
+	encoder *json.Encoder
+	closed  bool
+}
+
+// NewRecorder appends the records to the capture file at path.
+func NewRecorder(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open capture file: %w", err)
+	}
+	return &Recorder{w: file, encoder: json.NewEncoder(file)}, nil
+}
+
+// Record writes a single record. Records are dropped once the recorder is
+// closed, as the client may still be sending.
+func (r *Recorder) Record(rec Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	return r.encoder.Encode(rec)
+}
+
+// Close closes the capture file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return r.w.Close()
+}
+
+// ReadCapture reads all records of a capture file.
+func ReadCapture(r io.Reader) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
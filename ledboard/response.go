@@ -0,0 +1,164 @@
+package ledboard
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/utils"
+)
+
+// Status tells how a sign answered a request.
+type Status int
+
+const (
+	StatusTimeout Status = iota
+	StatusAcked
+	StatusRejected
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusAcked:
+		return "acked"
+	case StatusRejected:
+		return "rejected"
+	}
+	return "timeout"
+}
+
+// Policy controls how long to wait for an answer and how often to retry.
+type Policy struct {
+	Timeout time.Duration
+	Retries int
+}
+
+// DefaultPolicy waits a second for an answer and retries twice.
+var DefaultPolicy = Policy{Timeout: time.Second, Retries: 2}
+
+// Result is the outcome of a request to a single sign.
+type Result struct {
+	Sign     Sign
+	Status   Status
+	Attempts int
+
+	// Packet holds the sign's answer to read requests.
+	Packet *Packet
+}
+
+// DecodeResponse parses what a sign sent back: a single acknowledge or
+// reject byte, or a response packet framed by a checksum.
+func DecodeResponse(response string) (Status, *Packet, error) {
+	// Signs may prefix their answer with NUL bytes to wake up the line.
+	response = strings.TrimLeft(response, "\x00")
+
+	switch response {
+	case PacketAck:
+		return StatusAcked, nil, nil
+	case PacketNak:
+		return StatusRejected, nil, nil
+	}
+
+	d := &decoder{data: response}
+	if err := d.expect(PacketStartOfHeader, "start of header"); err != nil {
+		return StatusRejected, nil, err
+	}
+	header, err := d.take(3, "type and address")
+	if err != nil {
+		return StatusRejected, nil, err
+	}
+	if header[:1] != PacketTypeResponse {
+		return StatusRejected, nil, d.errorf(-3, "expected response type %q, got %q", PacketTypeResponse, header[:1])
+	}
+
+	start := d.pos
+	end := strings.LastIndex(response, PacketEndOfText)
+	if end < start {
+		return StatusRejected, nil, d.errorf(0, "missing end of text")
+	}
+	d.pos = end + 1
+	sum, err := d.take(4, "checksum")
+	if err != nil {
+		return StatusRejected, nil, err
+	}
+	if want := checksum(response[start : end+1]); sum != want {
+		return StatusRejected, nil, d.errorf(-4, "checksum %q does not match %q", sum, want)
+	}
+	if err := d.expect(ControlEnd, "end of transmission"); err != nil {
+		return StatusRejected, nil, err
+	}
+
+	// The payload between start and end of text is a regular packet body.
+	packet, err := Decode(PacketStartOfHeader + header + response[start:end] + ControlEnd)
+	if err != nil {
+		return StatusRejected, nil, fmt.Errorf("invalid response payload: %w", err)
+	}
+	return StatusAcked, packet, nil
+}
+
+// EncodeResponse frames a payload, like DateFunction(...), the way a sign
+// answers a read request.
+func EncodeResponse(address, payload string) string {
+	body := PacketStartOfText + payload + PacketEndOfText
+	return PacketStartOfHeader + PacketTypeResponse + address + body + checksum(body) + ControlEnd
+}
+
+// checksum sums up all bytes from start of text up to and including end of text.
+func checksum(body string) string {
+	var sum uint16
+	for i := 0; i < len(body); i++ {
+		sum += uint16(body[i])
+	}
+	return fmt.Sprintf("%04X", sum)
+}
+
+// DateFunction encodes the special function setting the sign's clock to date,
+// the weekday counted as SignWeekday does.
+func DateFunction(date time.Time) string {
+	var cmd string
+	cmd += CommandSpecialFunction + FunctionDate
+	cmd += utils.Byte2Hex(byte(date.Year()%100)) + utils.Byte2Hex(byte(date.Year()/100))
+	cmd += utils.Byte2Hex(byte(date.Month()))
+	cmd += utils.Byte2Hex(byte(date.Day()))
+	cmd += utils.Byte2Hex(byte(date.Hour()))
+	cmd += utils.Byte2Hex(byte(date.Minute()))
+	cmd += utils.Byte2Hex(byte(date.Second()))
+	cmd += utils.Byte2Hex(byte(SignWeekday(date.Weekday())))
+	return cmd
+}
+
+// SignWeekday returns the day of the week as the sign counts it, from 1 for
+// Sunday to 7 for Saturday. Specials like SpecialDDOfWeek show it like that.
+func SignWeekday(day time.Weekday) int {
+	return int(day) + 1
+}
+
+// MaxBrightness is the brightness of the sign in full daylight, in percent.
+const MaxBrightness = 100
+
+// BrightnessFunction encodes the special function dimming the sign to the
+// percentage of its full brightness, as two hex digits.
+func BrightnessFunction(percent int) string {
+	percent = min(max(percent, 0), MaxBrightness)
+	return CommandSpecialFunction + FunctionBrightness + fmt.Sprintf("%02X", percent)
+}
+
+// MemoryFunction encodes the special function holding the memory configuration.
+func MemoryFunction(allocations []FileAllocation) string {
+	cmd := CommandSpecialFunction + FunctionMemory
+	for _, allocation := range allocations {
+		cmd += allocation.encode()
+	}
+	return cmd
+}
+
+// StringFile encodes a string file the way it is written to and read from
+// the sign.
+func StringFile(label, value string) string {
+	return CommandWriteString + label + value
+}
+
+// TextFile encodes a text file the way it is written to and read from the sign.
+func TextFile(label, storage, screen string) string {
+	return CommandWriteText + FileText + label + storage + screen
+}
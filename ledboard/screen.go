@@ -0,0 +1,376 @@
+package ledboard
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Screen is a sequence of frames that the sign shows one after another.
+type Screen struct {
+	Frames []*Frame
+}
+
+// Frame is a single page of a screen, made of segments that are serialized in order.
+type Frame struct {
+	Segments []Segment
+}
+
+// Segment is a single piece of a frame, either text or a control sequence.
+type Segment interface {
+	encode(sb *strings.Builder) error
+}
+
+// NewScreen creates an empty screen.
+func NewScreen() *Screen {
+	return &Screen{}
+}
+
+// AddFrame appends a new, empty frame to the screen and returns it.
+func (s *Screen) AddFrame() *Frame {
+	frame := &Frame{}
+	s.Frames = append(s.Frames, frame)
+	return frame
+}
+
+// Join concatenates the frames of multiple screens into a single screen.
+func Join(screens ...*Screen) *Screen {
+	joined := NewScreen()
+	for _, screen := range screens {
+		joined.Frames = append(joined.Frames, screen.Frames...)
+	}
+	return joined
+}
+
+// Encode validates the screen and serializes it to the sign's byte stream.
+func (s *Screen) Encode() (string, error) {
+	var sb strings.Builder
+	for i, frame := range s.Frames {
+		if i > 0 {
+			sb.WriteString(ControlFrame)
+		}
+		if err := frame.encode(&sb); err != nil {
+			return "", fmt.Errorf("frame %d: %w", i, err)
+		}
+	}
+	return sb.String(), nil
+}
+
+func (f *Frame) encode(sb *strings.Builder) error {
+	lastContent := -1
+	for i, segment := range f.Segments {
+		switch segment.(type) {
+		case TextSegment, SpecialSegment, VariableSegment:
+			lastContent = i
+		}
+	}
+
+	for i, segment := range f.Segments {
+		if _, ok := segment.(FontSegment); ok && i > lastContent {
+			return fmt.Errorf("segment %d: font is not followed by any text", i)
+		}
+		if err := segment.encode(sb); err != nil {
+			return fmt.Errorf("segment %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (f *Frame) add(segment Segment) *Frame {
+	f.Segments = append(f.Segments, segment)
+	return f
+}
+
+// Text appends plain text to the frame. Control characters would be taken
+// as commands by the sign, they are replaced by spaces.
+func (f *Frame) Text(text string) *Frame { return f.add(TextSegment{replaceControl(text)}) }
+
+// Font switches the font for the following text.
+func (f *Frame) Font(font Font) *Frame { return f.add(FontSegment{font}) }
+
+// Color switches the font color for the following text.
+func (f *Frame) Color(color FontColor) *Frame { return f.add(ColorSegment{color}) }
+
+// Background switches the background color for the following text.
+func (f *Frame) Background(color BackgroundColor) *Frame {
+	return f.add(BackgroundSegment{color})
+}
+
+// Flash turns flashing of the following text on or off.
+func (f *Frame) Flash(on bool) *Frame { return f.add(FlashSegment{on}) }
+
+// PatternIn sets the pattern used to show the frame.
+func (f *Frame) PatternIn(pattern Pattern) *Frame { return f.add(PatternInSegment{pattern}) }
+
+// PatternOut sets the pattern used to hide the frame.
+func (f *Frame) PatternOut(pattern Pattern) *Frame { return f.add(PatternOutSegment{pattern}) }
+
+// Speed sets the animation speed of the patterns.
+func (f *Frame) Speed(speed Speed) *Frame { return f.add(SpeedSegment{speed}) }
+
+// AlignHorizontal sets the horizontal alignment of the following text.
+func (f *Frame) AlignHorizontal(align HorizontalAlign) *Frame {
+	return f.add(HorizontalAlignSegment{align})
+}
+
+// AlignVertical sets the vertical alignment of the following text.
+func (f *Frame) AlignVertical(align VerticalAlign) *Frame {
+	return f.add(VerticalAlignSegment{align})
+}
+
+// Special inserts a value the sign fills in itself, like the current time.
+func (f *Frame) Special(special Special) *Frame { return f.add(SpecialSegment{special}) }
+
+// LineFeed continues the following text on the next line.
+func (f *Frame) LineFeed() *Frame { return f.add(LineFeedSegment{}) }
+
+// HalfSpace inserts a space half as wide as a regular one.
+func (f *Frame) HalfSpace() *Frame { return f.add(HalfSpaceSegment{}) }
+
+// Picture shows the picture file with the given label inline with the text.
+func (f *Frame) Picture(label string) *Frame { return f.add(PictureSegment{Label: label}) }
+
+// Variable shows the value of the string file with the given label, which
+// can be changed without rewriting the text file showing it.
+func (f *Frame) Variable(label string) *Frame { return f.add(VariableSegment{Label: label}) }
+
+// PauseSeconds holds the frame for the given number of seconds (0-9999).
+func (f *Frame) PauseSeconds(seconds int) *Frame {
+	return f.add(PauseSegment{Value: seconds, Wide: seconds > 99})
+}
+
+// PauseMilliseconds holds the frame for the given number of milliseconds (0-9999).
+func (f *Frame) PauseMilliseconds(milliseconds int) *Frame {
+	return f.add(PauseSegment{Milliseconds: true, Value: milliseconds, Wide: milliseconds > 99})
+}
+
+// TextSegment is plain text. Control characters are sent as spaces.
+type TextSegment struct {
+	Text string
+}
+
+func (s TextSegment) encode(sb *strings.Builder) error {
+	sb.WriteString(replaceControl(s.Text))
+	return nil
+}
+
+// replaceControl replaces the control characters of text by spaces. Text is
+// in the sign's charset, bytes are replaced rather than runes.
+func replaceControl(text string) string {
+	if !strings.ContainsFunc(text, func(r rune) bool { return r < 0x20 || r == 0x7F }) {
+		return text
+	}
+	b := []byte(text)
+	for i, c := range b {
+		if c < 0x20 || c == 0x7F {
+			b[i] = ' '
+		}
+	}
+	return string(b)
+}
+
+// FontSegment switches the font.
+type FontSegment struct {
+	Font Font
+}
+
+func (s FontSegment) encode(sb *strings.Builder) error {
+	if !s.Font.Valid() {
+		return fmt.Errorf("unknown font %s", s.Font)
+	}
+	sb.WriteString(string(s.Font))
+	return nil
+}
+
+// ColorSegment switches the font color.
+type ColorSegment struct {
+	Color FontColor
+}
+
+func (s ColorSegment) encode(sb *strings.Builder) error {
+	if !s.Color.Valid() {
+		return fmt.Errorf("unknown font color %s", s.Color)
+	}
+	sb.WriteString(ControlFontColor + string(s.Color))
+	return nil
+}
+
+// BackgroundSegment switches the background color.
+type BackgroundSegment struct {
+	Color BackgroundColor
+}
+
+func (s BackgroundSegment) encode(sb *strings.Builder) error {
+	if !s.Color.Valid() {
+		return fmt.Errorf("unknown background color %s", s.Color)
+	}
+	sb.WriteString(ControlBackgroundColor + string(s.Color))
+	return nil
+}
+
+// FlashSegment turns flashing on or off.
+type FlashSegment struct {
+	On bool
+}
+
+func (s FlashSegment) encode(sb *strings.Builder) error {
+	sb.WriteString(ControlFlash)
+	if s.On {
+		sb.WriteString(FlashOn)
+	} else {
+		sb.WriteString(FlashOff)
+	}
+	return nil
+}
+
+// PatternInSegment sets the pattern used to show the frame.
+type PatternInSegment struct {
+	Pattern Pattern
+}
+
+func (s PatternInSegment) encode(sb *strings.Builder) error {
+	if !s.Pattern.Valid() {
+		return fmt.Errorf("unknown pattern %s", s.Pattern)
+	}
+	sb.WriteString(ControlPatternIn + string(s.Pattern))
+	return nil
+}
+
+// PatternOutSegment sets the pattern used to hide the frame.
+type PatternOutSegment struct {
+	Pattern Pattern
+}
+
+func (s PatternOutSegment) encode(sb *strings.Builder) error {
+	if !s.Pattern.Valid() {
+		return fmt.Errorf("unknown pattern %s", s.Pattern)
+	}
+	sb.WriteString(ControlPatternOut + string(s.Pattern))
+	return nil
+}
+
+// SpeedSegment sets the animation speed.
+type SpeedSegment struct {
+	Speed Speed
+}
+
+func (s SpeedSegment) encode(sb *strings.Builder) error {
+	if !s.Speed.Valid() {
+		return fmt.Errorf("unknown speed %s", s.Speed)
+	}
+	sb.WriteString(ControlSpeed + string(s.Speed))
+	return nil
+}
+
+// HorizontalAlignSegment sets the horizontal alignment.
+type HorizontalAlignSegment struct {
+	Align HorizontalAlign
+}
+
+func (s HorizontalAlignSegment) encode(sb *strings.Builder) error {
+	if !s.Align.Valid() {
+		return fmt.Errorf("unknown horizontal alignment %s", s.Align)
+	}
+	sb.WriteString(ControlAlignHorizontal + string(s.Align))
+	return nil
+}
+
+// VerticalAlignSegment sets the vertical alignment.
+type VerticalAlignSegment struct {
+	Align VerticalAlign
+}
+
+func (s VerticalAlignSegment) encode(sb *strings.Builder) error {
+	if !s.Align.Valid() {
+		return fmt.Errorf("unknown vertical alignment %s", s.Align)
+	}
+	sb.WriteString(ControlAlignVertical + string(s.Align))
+	return nil
+}
+
+// SpecialSegment inserts a value the sign fills in itself.
+type SpecialSegment struct {
+	Special Special
+}
+
+func (s SpecialSegment) encode(sb *strings.Builder) error {
+	if !s.Special.Valid() {
+		return fmt.Errorf("unknown special %s", s.Special)
+	}
+	sb.WriteString(ControlSpecial + string(s.Special))
+	return nil
+}
+
+// LineFeedSegment starts a new line.
+type LineFeedSegment struct{}
+
+func (s LineFeedSegment) encode(sb *strings.Builder) error {
+	sb.WriteString(ControlLineFeed)
+	return nil
+}
+
+// HalfSpaceSegment inserts a half-width space.
+type HalfSpaceSegment struct{}
+
+func (s HalfSpaceSegment) encode(sb *strings.Builder) error {
+	sb.WriteString(ControlHalfSpace)
+	return nil
+}
+
+// PictureSegment shows a picture file, which has to be written to the sign
+// beforehand.
+type PictureSegment struct {
+	Label string
+}
+
+func (s PictureSegment) encode(sb *strings.Builder) error {
+	if len(s.Label) != 1 || s.Label[0] < 0x20 || s.Label[0] > 0x7E {
+		return fmt.Errorf("invalid picture label %q", s.Label)
+	}
+	sb.WriteString(ControlPicture + s.Label)
+	return nil
+}
+
+// VariableSegment shows the current value of a string file, which has to be
+// allocated beforehand.
+type VariableSegment struct {
+	Label string
+}
+
+func (s VariableSegment) encode(sb *strings.Builder) error {
+	if len(s.Label) != 1 || s.Label[0] < 0x20 || s.Label[0] > 0x7E {
+		return fmt.Errorf("invalid string file label %q", s.Label)
+	}
+	sb.WriteString(ControlString + s.Label)
+	return nil
+}
+
+// PauseSegment holds the frame for Value seconds, or milliseconds if Milliseconds
+// is set. Wide selects the four digit form, which is required for values above 99.
+type PauseSegment struct {
+	Milliseconds bool
+	Wide         bool
+	Value        int
+}
+
+func (s PauseSegment) encode(sb *strings.Builder) error {
+	digits, limit := 2, 99
+	if s.Wide {
+		digits, limit = 4, 9999
+	}
+	if s.Value < 0 || s.Value > limit {
+		return fmt.Errorf("pause %d out of range 0-%d", s.Value, limit)
+	}
+
+	switch {
+	case s.Milliseconds && s.Wide:
+		sb.WriteString(PauseMillisecond4)
+	case s.Milliseconds:
+		sb.WriteString(PauseMillisecond2)
+	case s.Wide:
+		sb.WriteString(PauseSecond4)
+	default:
+		sb.WriteString(PauseSecond2)
+	}
+	fmt.Fprintf(sb, "%0*d", digits, s.Value)
+	return nil
+}
@@ -0,0 +1,192 @@
+package ledboard_test
+
+import (
+	"testing"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+)
+
+// The old screens were strings concatenated from the control codes, these
+// spell the codes the same way.
+func in(pattern ledboard.Pattern) string  { return ledboard.ControlPatternIn + string(pattern) }
+func out(pattern ledboard.Pattern) string { return ledboard.ControlPatternOut + string(pattern) }
+func color(color ledboard.FontColor) string {
+	return ledboard.ControlFontColor + string(color)
+}
+func special(special ledboard.Special) string { return ledboard.ControlSpecial + string(special) }
+
+const (
+	flashOn  = ledboard.ControlFlash + ledboard.FlashOn
+	flashOff = ledboard.ControlFlash + ledboard.FlashOff
+)
+
+// frames builds a screen of a frame per function.
+func frames(build ...func(f *ledboard.Frame)) *ledboard.Screen {
+	screen := ledboard.NewScreen()
+	for _, b := range build {
+		b(screen.AddFrame())
+	}
+	return screen
+}
+
+func TestEncodeMatchesStrings(t *testing.T) {
+	congratulations := func(f *ledboard.Frame) { f.Text("Congratulations!").PauseMilliseconds(400) }
+	blank := func(f *ledboard.Frame) { f.Text(" ").PauseMilliseconds(100) }
+	welcome := func(c ledboard.FontColor) func(f *ledboard.Frame) {
+		return func(f *ledboard.Frame) {
+			f.Font(ledboard.FontNormal7x6).Color(c).Text("Herzlich Willkommen im backspace!").PauseSeconds(1)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		screen *ledboard.Screen
+		want   string
+	}{
+		{
+			name: "alarm",
+			screen: frames(func(f *ledboard.Frame) {
+				f.PatternIn(ledboard.PatternRadarScan).Font(ledboard.FontNormal16x9).Flash(true).
+					Color(ledboard.FontColorRed).Text("!  ALARM  !").Flash(false).PauseSeconds(4)
+			}, func(f *ledboard.Frame) {
+				f.Font(ledboard.FontNormal7x6).Color(ledboard.FontColorGreen).PatternIn(ledboard.PatternMoveUp).
+					PatternOut(ledboard.PatternMoveLeft).Text("Fire").PauseSeconds(30)
+			}),
+			want: in(ledboard.PatternRadarScan) + string(ledboard.FontNormal16x9) + flashOn + color(ledboard.FontColorRed) +
+				"!  ALARM  !" + flashOff + ledboard.PauseSecond2 + "04" + ledboard.ControlFrame +
+				string(ledboard.FontNormal7x6) + color(ledboard.FontColorGreen) + in(ledboard.PatternMoveUp) +
+				out(ledboard.PatternMoveLeft) + "Fire" + ledboard.PauseSecond2 + "30",
+		},
+		{
+			name: "donation",
+			screen: frames(func(f *ledboard.Frame) {
+				f.Font(ledboard.FontNormal16x9).PatternIn(ledboard.PatternScrollUp).PatternOut(ledboard.PatternScrollUp).
+					Flash(true).Color(ledboard.FontColorYGRCharacter).Text("\\o/ Spende! \\o/").Flash(false).PauseSeconds(4)
+			}),
+			want: string(ledboard.FontNormal16x9) + in(ledboard.PatternScrollUp) + out(ledboard.PatternScrollUp) + flashOn +
+				color(ledboard.FontColorYGRCharacter) + "\\o/ Spende! \\o/" + flashOff + ledboard.PauseSecond2 + "04",
+		},
+		{
+			name: "idle",
+			screen: frames(func(f *ledboard.Frame) {
+				f.Font(ledboard.FontNormal7x6).PatternIn(ledboard.PatternScrollUp).PatternOut(ledboard.PatternScrollUp).
+					Color(ledboard.FontColorGreen).
+					Special(ledboard.SpecialYYYY).Text("-").Special(ledboard.SpecialMM).Text("-").
+					Special(ledboard.SpecialDD).Text(" ").
+					Color(ledboard.FontColorRed).
+					Special(ledboard.SpecialHH).Text(":").Special(ledboard.SpecialMIN).Text(":").
+					Special(ledboard.SpecialSEC).LineFeed().
+					Color(ledboard.FontColorYellow).Text("members present: 3").PauseSeconds(9999)
+			}),
+			want: string(ledboard.FontNormal7x6) + in(ledboard.PatternScrollUp) + out(ledboard.PatternScrollUp) +
+				color(ledboard.FontColorGreen) +
+				special(ledboard.SpecialYYYY) + "-" + special(ledboard.SpecialMM) + "-" + special(ledboard.SpecialDD) + " " +
+				color(ledboard.FontColorRed) +
+				special(ledboard.SpecialHH) + ":" + special(ledboard.SpecialMIN) + ":" + special(ledboard.SpecialSEC) +
+				ledboard.ControlLineFeed + color(ledboard.FontColorYellow) + "members present: 3" +
+				ledboard.PauseSecond4 + "9999",
+		},
+		{
+			name: "laser-finished",
+			screen: frames(func(f *ledboard.Frame) {
+				f.PatternIn(ledboard.PatternScrollUp).PatternOut(ledboard.PatternScrollUp).
+					Font(ledboard.FontNormal14x8).Color(ledboard.FontColorGreen).
+					Text("Congratulations!").PauseMilliseconds(400)
+			}, blank, congratulations, blank, congratulations, blank, func(f *ledboard.Frame) {
+				f.PatternIn(ledboard.PatternPeelOffR).Font(ledboard.FontNormal7x6).Color(ledboard.FontColorGreen).
+					Flash(false).Text("Laser-Job finished:").LineFeed().Color(ledboard.FontColorRed).
+					Text("1h 2m 3s").PauseSeconds(120)
+			}),
+			want: in(ledboard.PatternScrollUp) + out(ledboard.PatternScrollUp) + string(ledboard.FontNormal14x8) +
+				color(ledboard.FontColorGreen) +
+				"Congratulations!" + ledboard.PauseMillisecond4 + "0400" + ledboard.ControlFrame +
+				" " + ledboard.PauseMillisecond4 + "0100" + ledboard.ControlFrame +
+				"Congratulations!" + ledboard.PauseMillisecond4 + "0400" + ledboard.ControlFrame +
+				" " + ledboard.PauseMillisecond4 + "0100" + ledboard.ControlFrame +
+				"Congratulations!" + ledboard.PauseMillisecond4 + "0400" + ledboard.ControlFrame +
+				" " + ledboard.PauseMillisecond4 + "0100" + ledboard.ControlFrame +
+				in(ledboard.PatternPeelOffR) + string(ledboard.FontNormal7x6) + color(ledboard.FontColorGreen) + flashOff +
+				"Laser-Job finished:" + ledboard.ControlLineFeed + color(ledboard.FontColorRed) + "1h 2m 3s" +
+				ledboard.PauseSecond4 + "0120",
+		},
+		{
+			name: "laser-operation",
+			screen: frames(func(f *ledboard.Frame) {
+				f.PatternIn(ledboard.PatternRadarScan).Font(ledboard.FontNormal15x9).Color(ledboard.FontColorRed).
+					Special(ledboard.SpecialHH).Text("h ").Special(ledboard.SpecialMIN).Text("m ").
+					Special(ledboard.SpecialSEC).Text("s ").PauseSeconds(9999)
+			}),
+			want: in(ledboard.PatternRadarScan) + string(ledboard.FontNormal15x9) + color(ledboard.FontColorRed) +
+				special(ledboard.SpecialHH) + "h " + special(ledboard.SpecialMIN) + "m " + special(ledboard.SpecialSEC) + "s " +
+				ledboard.PauseSecond4 + "9999",
+		},
+		{
+			name: "new-member",
+			screen: frames(func(f *ledboard.Frame) {
+				f.PatternIn(ledboard.PatternRadarScan)
+				welcome(ledboard.FontColorGreen)(f)
+			}, welcome(ledboard.FontColorRed), welcome(ledboard.FontColorYGRHorizontal), func(f *ledboard.Frame) {
+				f.Font(ledboard.FontNormal16x9).Color(ledboard.FontColorYellow).PatternIn(ledboard.PatternMoveUp).
+					PatternOut(ledboard.PatternMoveLeft).Text("nick").PauseSeconds(30)
+			}),
+			want: in(ledboard.PatternRadarScan) +
+				string(ledboard.FontNormal7x6) + color(ledboard.FontColorGreen) + "Herzlich Willkommen im backspace!" +
+				ledboard.PauseSecond2 + "01" + ledboard.ControlFrame +
+				string(ledboard.FontNormal7x6) + color(ledboard.FontColorRed) + "Herzlich Willkommen im backspace!" +
+				ledboard.PauseSecond2 + "01" + ledboard.ControlFrame +
+				string(ledboard.FontNormal7x6) + color(ledboard.FontColorYGRHorizontal) + "Herzlich Willkommen im backspace!" +
+				ledboard.PauseSecond2 + "01" + ledboard.ControlFrame +
+				string(ledboard.FontNormal16x9) + color(ledboard.FontColorYellow) + in(ledboard.PatternMoveUp) +
+				out(ledboard.PatternMoveLeft) + "nick" + ledboard.PauseSecond2 + "30",
+		},
+		{
+			name: "psa",
+			screen: frames(func(f *ledboard.Frame) {
+				f.PatternIn(ledboard.PatternRadarScan).Flash(true).Font(ledboard.FontNormal7x6).
+					Color(ledboard.FontColorYellow).Text("PUBLIC ").Color(ledboard.FontColorRed).Text("SERVICE ").
+					Color(ledboard.FontColorGreen).Text("ANNOUNCEMENT").Flash(false).PauseSeconds(5)
+			}, func(f *ledboard.Frame) {
+				f.Text("Lunch").PauseSeconds(45)
+			}),
+			want: in(ledboard.PatternRadarScan) + flashOn + string(ledboard.FontNormal7x6) +
+				color(ledboard.FontColorYellow) + "PUBLIC " + color(ledboard.FontColorRed) + "SERVICE " +
+				color(ledboard.FontColorGreen) + "ANNOUNCEMENT" + flashOff + ledboard.PauseSecond2 + "05" +
+				ledboard.ControlFrame + "Lunch" + ledboard.PauseSecond2 + "45",
+		},
+		{
+			name: "control characters",
+			screen: frames(func(f *ledboard.Frame) {
+				f.Font(ledboard.FontNormal7x6).Text("a\x03b\x04c\x7f").PauseMilliseconds(5)
+			}),
+			want: string(ledboard.FontNormal7x6) + "a b c " + ledboard.PauseMillisecond2 + "05",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.screen.Encode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("encoded\n%q, want\n%q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEncodeRejects(t *testing.T) {
+	tests := map[string]*ledboard.Screen{
+		"font without text":   frames(func(f *ledboard.Frame) { f.Text("a").Font(ledboard.FontNormal7x6) }),
+		"unknown font":        frames(func(f *ledboard.Frame) { f.Font("\x1Az").Text("a") }),
+		"unknown color":       frames(func(f *ledboard.Frame) { f.Color("z").Text("a") }),
+		"pause out of range":  frames(func(f *ledboard.Frame) { f.Text("a").PauseSeconds(10000) }),
+		"negative pause":      frames(func(f *ledboard.Frame) { f.Text("a").PauseSeconds(-1) }),
+		"invalid string file": frames(func(f *ledboard.Frame) { f.Variable("ab") }),
+	}
+	for name, screen := range tests {
+		if encoded, err := screen.Encode(); err == nil {
+			t.Errorf("%s: encoded as %q", name, encoded)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+//go:build linux
+
+package ledboard
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// cbaud masks the baud rate bits of the control flags, missing in package
+// syscall.
+const cbaud = 0x100f
+
+var baudRates = map[int]uint32{
+	1200:   syscall.B1200,
+	2400:   syscall.B2400,
+	4800:   syscall.B4800,
+	9600:   syscall.B9600,
+	19200:  syscall.B19200,
+	38400:  syscall.B38400,
+	57600:  syscall.B57600,
+	115200: syscall.B115200,
+	230400: syscall.B230400,
+}
+
+// Flags of struct serial_rs485.
+const (
+	serialRS485Enabled   = 1 << 0
+	serialRS485RTSOnSend = 1 << 1
+)
+
+// serialRS485 is struct serial_rs485 of linux/serial.h.
+type serialRS485 struct {
+	flags              uint32
+	delayRTSBeforeSend uint32
+	delayRTSAfterSend  uint32
+	padding            [5]uint32
+}
+
+// serialTransport streams packets over a serial line in raw mode.
+type serialTransport struct {
+	file *os.File
+
+	// mu guards reader, which is replaced after a read error.
+	mu     sync.Mutex
+	reader *bufio.Scanner
+}
+
+func openSerial(path string, options SerialOptions) (Transport, error) {
+	speed, ok := baudRates[options.Baud]
+	if !ok {
+		return nil, fmt.Errorf("unsupported baud rate %d", options.Baud)
+	}
+
+	// Opened non-blocking, the runtime poller then lets Close interrupt reads.
+	file, err := os.OpenFile(path, os.O_RDWR|syscall.O_NOCTTY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open serial line: %w", err)
+	}
+
+	conn, err := file.SyscallConn()
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to configure serial line %s: %w", path, err)
+	}
+	var ioctlErr error
+	err = conn.Control(func(fd uintptr) {
+		var termios syscall.Termios
+		if ioctlErr = ioctl(fd, syscall.TCGETS, unsafe.Pointer(&termios)); ioctlErr != nil {
+			return
+		}
+		configureTermios(&termios, speed, options)
+		if ioctlErr = ioctl(fd, syscall.TCSETS, unsafe.Pointer(&termios)); ioctlErr != nil {
+			return
+		}
+		if options.RS485 {
+			rs485 := serialRS485{flags: serialRS485Enabled | serialRS485RTSOnSend}
+			if err := ioctl(fd, syscall.TIOCSRS485, unsafe.Pointer(&rs485)); err != nil {
+				ioctlErr = fmt.Errorf("failed to enable RS-485 mode: %w", err)
+			}
+		}
+	})
+	if err == nil {
+		err = ioctlErr
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to configure serial line %s: %w", path, err)
+	}
+
+	return &serialTransport{file: file, reader: newPacketScanner(file)}, nil
+}
+
+// configureTermios sets up raw mode, like cfmakeraw, with the line settings
+// of the options.
+func configureTermios(termios *syscall.Termios, speed uint32, options SerialOptions) {
+	termios.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON | syscall.IXOFF | syscall.INPCK
+	termios.Oflag &^= syscall.OPOST
+	termios.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	termios.Cflag &^= cbaud | syscall.CSIZE | syscall.PARENB | syscall.PARODD | syscall.CSTOPB
+	termios.Cflag |= speed | syscall.CREAD | syscall.CLOCAL
+
+	if options.DataBits == 7 {
+		termios.Cflag |= syscall.CS7
+	} else {
+		termios.Cflag |= syscall.CS8
+	}
+	if options.StopBits == 2 {
+		termios.Cflag |= syscall.CSTOPB
+	}
+	switch options.Parity {
+	case "even":
+		termios.Cflag |= syscall.PARENB
+		termios.Iflag |= syscall.INPCK
+	case "odd":
+		termios.Cflag |= syscall.PARENB | syscall.PARODD
+		termios.Iflag |= syscall.INPCK
+	}
+
+	termios.Ispeed, termios.Ospeed = speed, speed
+	termios.Cc[syscall.VMIN] = 1
+	termios.Cc[syscall.VTIME] = 0
+}
+
+func ioctl(fd uintptr, request uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func (t *serialTransport) Send(packet []byte) error {
+	_, err := t.file.Write(packet)
+	return err
+}
+
+func (t *serialTransport) Receive() ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.reader.Scan() {
+		return t.reader.Bytes(), nil
+	}
+	err := t.reader.Err()
+	if errors.Is(err, os.ErrClosed) {
+		return nil, fmt.Errorf("serial line closed: %w", net.ErrClosed)
+	}
+	if err == nil {
+		err = fmt.Errorf("serial line hung up")
+	}
+
+	// A scanner stops at the first error, start over with a new one.
+	time.Sleep(time.Second)
+	t.reader = newPacketScanner(t.file)
+	return nil, err
+}
+
+func (t *serialTransport) Close() error {
+	return t.file.Close()
+}
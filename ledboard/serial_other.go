@@ -0,0 +1,9 @@
+//go:build !linux
+
+package ledboard
+
+import "fmt"
+
+func openSerial(path string, options SerialOptions) (Transport, error) {
+	return nil, fmt.Errorf("serial line %s: serial lines are only supported on Linux", path)
+}
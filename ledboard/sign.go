@@ -0,0 +1,166 @@
+package ledboard
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultPort is the UDP port the sign listens on.
+const DefaultPort = 9520
+
+// DefaultMaxPacketUDP is the largest packet sent to signs reached over UDP by
+// default, what fits into a single Ethernet frame.
+const DefaultMaxPacketUDP = 1472
+
+// echoPort is the TCP echo service of network signs, used to probe them.
+const echoPort = 7
+
+// Sign describes a single sign and how to reach it.
+type Sign struct {
+	Name string
+	// URL selects the transport, see Dial.
+	URL *url.URL
+
+	// Type is the sign type code, PacketTypeAll addresses every type.
+	Type string
+	// Address is the two character sign address, PacketAddressAll is the broadcast address.
+	Address string
+
+	// Checksum frames packets with a checksum, so the sign drops corrupted
+	// ones instead of showing garbage.
+	Checksum bool
+	// MaxPacket is the size of the largest packet the sign or the path to it
+	// handles, zero if there is no limit.
+	MaxPacket int
+}
+
+// ParseSign parses a sign specification of the form "[name=]url[@TAA]",
+// where T is the type code and AA the address, e.g. "front=udp://10.0.0.5@Z01"
+// or "lab=serial:///dev/ttyUSB0?baud=9600". A bare "host[:port]" is reached
+// over UDP. The name defaults to the host or the serial device.
+//
+// The query options "checksum=true" and "maxpacket=bytes" apply to every
+// transport, e.g. "udp://10.0.0.5?checksum=true&maxpacket=512".
+func ParseSign(spec string) (Sign, error) {
+	sign := Sign{Type: PacketTypeAll, Address: PacketAddressAll}
+
+	// Names are plain words, an equals sign after a scheme or path belongs
+	// to the query of the URL.
+	if name, rest, ok := strings.Cut(spec, "="); ok && !strings.ContainsAny(name, ":/?") {
+		sign.Name, spec = name, rest
+	}
+	if i := strings.LastIndex(spec, "@"); i >= 0 {
+		address := spec[i+1:]
+		if len(address) != 3 {
+			return Sign{}, fmt.Errorf("sign %q: address %q must be a type code followed by two address characters", spec, address)
+		}
+		sign.Type, sign.Address = address[:1], address[1:]
+		spec = spec[:i]
+	}
+
+	if !strings.Contains(spec, "://") {
+		spec = "udp://" + spec
+	}
+	u, err := url.Parse(spec)
+	if err != nil {
+		return Sign{}, fmt.Errorf("sign %q: %w", spec, err)
+	}
+	switch u.Scheme {
+	case "udp", "tcp":
+		if u.Hostname() == "" {
+			return Sign{}, fmt.Errorf("sign %q: missing host", spec)
+		}
+		if u.Port() == "" {
+			if u.Scheme == "tcp" {
+				return Sign{}, fmt.Errorf("sign %q: missing port", spec)
+			}
+			u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(DefaultPort))
+		}
+		if _, err := strconv.Atoi(u.Port()); err != nil {
+			return Sign{}, fmt.Errorf("sign %q: invalid port %q", spec, u.Port())
+		}
+	case "serial":
+		if u.Path == "" {
+			return Sign{}, fmt.Errorf("sign %q: missing serial device", spec)
+		}
+		if _, err := parseSerialOptions(u.Query()); err != nil {
+			return Sign{}, fmt.Errorf("sign %q: %w", spec, err)
+		}
+	default:
+		return Sign{}, fmt.Errorf("sign %q: unknown transport %q", spec, u.Scheme)
+	}
+	sign.URL = u
+	if err := parsePacketOptions(&sign, u.Query()); err != nil {
+		return Sign{}, fmt.Errorf("sign %q: %w", spec, err)
+	}
+
+	if sign.Name == "" {
+		sign.Name = u.Hostname()
+		if u.Scheme == "serial" {
+			sign.Name = u.Path
+		}
+	}
+	return sign, nil
+}
+
+func parsePacketOptions(sign *Sign, query url.Values) error {
+	if value := query.Get("checksum"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid option checksum=%q", value)
+		}
+		sign.Checksum = enabled
+	}
+
+	if sign.URL.Scheme == "udp" {
+		sign.MaxPacket = DefaultMaxPacketUDP
+	}
+	if value := query.Get("maxpacket"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 0 {
+			return fmt.Errorf("invalid option maxpacket=%q", value)
+		}
+		sign.MaxPacket = size
+	}
+	return nil
+}
+
+// Host returns the network host of the sign, empty for serial lines.
+func (s Sign) Host() string {
+	return s.URL.Hostname()
+}
+
+// ProbeAddress returns the TCP address telling whether the sign is online:
+// the echo service of signs reached over UDP, the converter of signs reached
+// over TCP. Serial lines have none.
+func (s Sign) ProbeAddress() string {
+	switch s.URL.Scheme {
+	case "udp":
+		return net.JoinHostPort(s.Host(), strconv.Itoa(echoPort))
+	case "tcp":
+		return s.URL.Host
+	}
+	return ""
+}
+
+// header returns the packet header addressing this sign.
+func (s Sign) header() string {
+	return PacketStartOfHeader + s.Type + s.Address + PacketStartOfText
+}
+
+// packet frames a command for this sign, followed by a checksum if the sign
+// wants one.
+func (s Sign) packet(command string) string {
+	if !s.Checksum {
+		return s.header() + command + ControlEnd
+	}
+	body := PacketStartOfText + command + PacketEndOfText
+	return PacketStartOfHeader + s.Type + s.Address + body + checksum(body) + ControlEnd
+}
+
+func (s Sign) String() string {
+	return fmt.Sprintf("%s (%s@%s%s)", s.Name, s.URL, s.Type, s.Address)
+}
@@ -0,0 +1,202 @@
+package ledboard
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// SplitScreen cuts the screen at frame boundaries into screens that each fit
+// into a single text file packet for every sign of the client. Written to
+// consecutive files of the run sequence they show the same as the whole
+// screen. Frames too long for a file are paged across several frames. A
+// screen that fits is returned as is.
+func (c *Client) SplitScreen(screen *Screen) ([]*Screen, error) {
+	limit, limited := 0, false
+	for _, t := range c.targets {
+		if t.sign.MaxPacket == 0 {
+			continue
+		}
+		// The text file packet without any text, labels are a single character.
+		room := t.sign.MaxPacket - len(t.sign.packet(TextFile(DefaultFile, StorageFlash, "")))
+		if !limited || room < limit {
+			limit, limited = room, true
+		}
+	}
+	if !limited {
+		return []*Screen{screen}, nil
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("%w: no room for any text", ErrPacketTooLarge)
+	}
+	return splitScreen(screen, limit)
+}
+
+// splitScreen cuts the screen into screens whose encoding is at most limit
+// bytes long.
+func splitScreen(screen *Screen, limit int) ([]*Screen, error) {
+	var parts []*Screen
+	part, size := NewScreen(), 0
+	for i, frame := range screen.Frames {
+		pages, err := pageFrame(frame, limit)
+		if err != nil {
+			return nil, fmt.Errorf("frame %d: %w", i, err)
+		}
+		for _, page := range pages {
+			encoded, err := (&Screen{Frames: []*Frame{page}}).Encode()
+			if err != nil {
+				return nil, fmt.Errorf("frame %d: %w", i, err)
+			}
+			if len(encoded) > limit {
+				return nil, fmt.Errorf("frame %d: %w: %d bytes, a file takes %d", i, ErrPacketTooLarge, len(encoded), limit)
+			}
+
+			// Frames after the first are preceded by a frame separator.
+			if len(part.Frames) > 0 && size+len(ControlFrame)+len(encoded) > limit {
+				parts = append(parts, part)
+				part, size = NewScreen(), 0
+			}
+			if len(part.Frames) > 0 {
+				size += len(ControlFrame)
+			}
+			part.Frames = append(part.Frames, page)
+			size += len(encoded)
+		}
+	}
+	return append(parts, part), nil
+}
+
+// pageFrame pages a frame whose encoding is longer than limit bytes across
+// frames that fit. Every page starts with the patterns, alignment, colors
+// and font in effect, text is cut between words where possible. Segments
+// after the last text, like a closing pause, stay on a page with some of it.
+// A frame that fits is returned as is.
+func pageFrame(frame *Frame, limit int) ([]*Frame, error) {
+	var sb strings.Builder
+	if err := frame.encode(&sb); err != nil {
+		return nil, err
+	}
+	if sb.Len() <= limit {
+		return []*Frame{frame}, nil
+	}
+
+	var (
+		pages []*Frame
+		style []Segment
+		page  *Frame
+		size  int
+		// content is set once the page shows something beyond its style.
+		content bool
+	)
+	add := func(segment Segment, length int) {
+		page.Segments = append(page.Segments, segment)
+		size += length
+	}
+	startPage := func() {
+		page, size, content = &Frame{}, 0, false
+		for _, segment := range style {
+			add(segment, segmentLength(segment))
+		}
+	}
+	endPage := func() {
+		// The style at the end of the page is in effect on the next one.
+		for n := len(page.Segments); n > 0 && isStyle(page.Segments[n-1]); n-- {
+			page.Segments = page.Segments[:n-1]
+		}
+		pages = append(pages, page)
+		startPage()
+	}
+
+	// The segments after the last text, like a closing pause, stay on its
+	// page, a page without text has no use for the font in front of it.
+	last, trailing := -1, 0
+	for i, segment := range frame.Segments {
+		switch segment.(type) {
+		case TextSegment, SpecialSegment, VariableSegment:
+			last, trailing = i, 0
+		default:
+			trailing += segmentLength(segment)
+		}
+	}
+
+	startPage()
+	for i, segment := range frame.Segments {
+		length := segmentLength(segment)
+		reserve := 0
+		if i == last {
+			reserve = trailing
+		}
+		if isStyle(segment) {
+			style = slices.DeleteFunc(style, func(s Segment) bool { return reflect.TypeOf(s) == reflect.TypeOf(segment) })
+			style = append(style, segment)
+			add(segment, length)
+			continue
+		}
+
+		text, ok := segment.(TextSegment)
+		if !ok {
+			if size+length+reserve > limit && content {
+				endPage()
+			}
+			if size+length+reserve > limit {
+				return nil, fmt.Errorf("%w: segment of %d bytes, a file takes %d", ErrPacketTooLarge, length, limit)
+			}
+			add(segment, length)
+			content = true
+			continue
+		}
+
+		rest := replaceControl(text.Text)
+		for rest != "" {
+			room := limit - size
+			if len(rest)+reserve <= room {
+				add(TextSegment{rest}, len(rest))
+				content = true
+				break
+			}
+			// Cut after the last space that fits, else start the word on
+			// the next page, unless it doesn't fit on a page by itself. The
+			// trailing segments take some of the text to their page.
+			window := max(min(room, len(rest)-1), 0)
+			cut := strings.LastIndexByte(rest[:window], ' ') + 1
+			if cut == 0 && content {
+				endPage()
+				continue
+			}
+			if cut == 0 {
+				cut = window
+			}
+			if cut <= 0 {
+				return nil, fmt.Errorf("%w: no room for text after the style, a file takes %d bytes", ErrPacketTooLarge, limit)
+			}
+			add(TextSegment{rest[:cut]}, cut)
+			content = true
+			rest = rest[cut:]
+			endPage()
+		}
+	}
+	if content {
+		endPage()
+	}
+	return pages, nil
+}
+
+// isStyle reports whether the segment changes how the following text is
+// shown, rather than showing something itself.
+func isStyle(segment Segment) bool {
+	switch segment.(type) {
+	case FontSegment, ColorSegment, BackgroundSegment, FlashSegment, PatternInSegment, PatternOutSegment,
+		SpeedSegment, HorizontalAlignSegment, VerticalAlignSegment:
+		return true
+	}
+	return false
+}
+
+// segmentLength returns the length of the segment's encoding. Segments are
+// only measured once their frame was encoded.
+func segmentLength(segment Segment) int {
+	var sb strings.Builder
+	_ = segment.encode(&sb)
+	return sb.Len()
+}
@@ -0,0 +1,106 @@
+package ledboard_test
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+	"github.com/b4ckspace/ledboard-v2/screens"
+)
+
+// discard is where the test signs are reached, nothing is sent.
+var discard = &url.URL{Scheme: "udp", Host: "127.0.0.1:9"}
+
+// minSplitLimit is the smallest room for text in a file the tests split at,
+// enough for the style the templates put in front of their text.
+const minSplitLimit = 48
+
+func TestSplitScreen(t *testing.T) {
+	s := screens.NewScreens(screens.DefaultBoard, nil)
+	words := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 40)
+	tests := map[string]*ledboard.Screen{
+		"short":      s.PublicServiceAnnouncement("Lunch is ready"),
+		"psa-1447":   s.PublicServiceAnnouncement(words[:1447]),
+		"psa-1448":   s.PublicServiceAnnouncement(words[:1448]),
+		"psa-long":   s.PublicServiceAnnouncement(words),
+		"one-word":   s.PublicServiceAnnouncement(strings.Repeat("x", 2000)),
+		"alarm":      s.Alarm(words[:900]),
+		"finished":   s.LaserFinished(3*3600 + 25*60 + 7),
+		"idle":       s.Idle(),
+		"new-member": s.NewMemberRegistration(words[:700]),
+		"styled":     styled(words),
+	}
+
+	for name, screen := range tests {
+		want := screenText(screen)
+		for _, sign := range signs {
+			header := len(sign.Packet(ledboard.TextFile(ledboard.DefaultFile, ledboard.StorageFlash, "")))
+			for limit := minSplitLimit; limit <= ledboard.DefaultMaxPacketUDP-header; limit++ {
+				sign.URL, sign.MaxPacket = discard, limit+header
+				if err := checkSplit(sign, screen, want); err != nil {
+					t.Fatalf("%s at %d bytes: %v", name, limit, err)
+				}
+			}
+		}
+	}
+}
+
+// styled returns a screen of long text between style changes, line feeds
+// and pauses.
+func styled(words string) *ledboard.Screen {
+	screen := ledboard.NewScreen()
+	screen.AddFrame().PatternIn(ledboard.PatternMoveLeft).Font(ledboard.FontNormal7x6).Color(ledboard.FontColorRed).
+		Text(words[:600]).LineFeed().Color(ledboard.FontColorGreen).Text(words[:500]).PauseSeconds(3)
+	screen.AddFrame().Text(words[:300]).HalfSpace().PauseMilliseconds(500)
+	return screen
+}
+
+// checkSplit splits the screen for the sign and checks that every part
+// fits, decodes and that together they show the text of the screen.
+func checkSplit(sign ledboard.Sign, screen *ledboard.Screen, want string) error {
+	client, err := ledboard.NewClient([]ledboard.Sign{sign}, nil, ledboard.QueueOptions{})
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	parts, err := client.SplitScreen(screen)
+	if err != nil {
+		return err
+	}
+	var got strings.Builder
+	for i, part := range parts {
+		encoded, err := part.Encode()
+		if err != nil {
+			return fmt.Errorf("part %d: %w", i, err)
+		}
+		packet := sign.Packet(ledboard.TextFile(ledboard.DefaultFile, ledboard.StorageFlash, encoded))
+		if len(packet) > sign.MaxPacket {
+			return fmt.Errorf("part %d: %w: %d bytes", i, ledboard.ErrPacketTooLarge, len(packet))
+		}
+		decoded, err := ledboard.Decode(packet)
+		if err != nil {
+			return fmt.Errorf("part %d: %w", i, err)
+		}
+		got.WriteString(screenText(decoded.Screen))
+	}
+	if got.String() != want {
+		return fmt.Errorf("text of the parts differs from the screen's\n got: %q\nwant: %q", got.String(), want)
+	}
+	return nil
+}
+
+// screenText returns the text shown by the screen, without its style.
+func screenText(screen *ledboard.Screen) string {
+	var sb strings.Builder
+	for _, frame := range screen.Frames {
+		for _, segment := range frame.Segments {
+			if text, ok := segment.(ledboard.TextSegment); ok {
+				sb.WriteString(text.Text)
+			}
+		}
+	}
+	return sb.String()
+}
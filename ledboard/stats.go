@@ -0,0 +1,52 @@
+package ledboard
+
+import "time"
+
+// SignStats counts what was sent to a sign.
+type SignStats struct {
+	Sign Sign
+
+	// Packets and Bytes count what was written to the transport.
+	Packets uint64
+	Bytes   uint64
+	// Errors counts failed writes and reads of the transport.
+	Errors uint64
+
+	LastSuccess   time.Time
+	LastError     error
+	LastErrorTime time.Time
+
+	Queue QueueStats
+}
+
+// Failing reports whether the transport failed since the last packet was
+// written.
+func (s SignStats) Failing() bool {
+	return s.LastErrorTime.After(s.LastSuccess)
+}
+
+func (t *target) succeeded(bytes int) {
+	t.statsMu.Lock()
+	defer t.statsMu.Unlock()
+	t.stats.Packets++
+	t.stats.Bytes += uint64(bytes)
+	t.stats.LastSuccess = time.Now()
+}
+
+func (t *target) failed(err error) {
+	t.statsMu.Lock()
+	defer t.statsMu.Unlock()
+	t.stats.Errors++
+	t.stats.LastError = err
+	t.stats.LastErrorTime = time.Now()
+}
+
+func (t *target) snapshot() SignStats {
+	t.statsMu.Lock()
+	stats := t.stats
+	t.statsMu.Unlock()
+
+	stats.Sign = t.sign
+	stats.Queue = t.queue.snapshot()
+	return stats
+}
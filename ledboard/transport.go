@@ -0,0 +1,300 @@
+package ledboard
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Transport carries packets to a sign and its answers back.
+type Transport interface {
+	// Send writes a single packet.
+	Send(packet []byte) error
+	// Receive waits for the next answer of the sign. Once the transport is
+	// closed it returns an error wrapping net.ErrClosed.
+	Receive() ([]byte, error)
+	Close() error
+}
+
+// Dial opens the transport selected by the URL:
+//
+//   - udp://host[:port] sends a datagram per packet, to port 9520 by default
+//   - tcp://host:port streams packets, e.g. to a TCP to serial converter,
+//     and reconnects when the connection breaks
+//   - serial:///dev/ttyUSB0?baud=9600&parity=even&databits=7&stopbits=2&rs485=true
+//     drives a serial line, by default with 9600 baud, 8 data bits, no parity
+//     and a stop bit
+func Dial(u *url.URL) (Transport, error) {
+	switch u.Scheme {
+	case "udp":
+		addr, err := net.ResolveUDPAddr("udp4", u.Host)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve UDP address %s: %w", u.Host, err)
+		}
+		conn, err := net.DialUDP("udp4", nil, addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial UDP %s: %w", u.Host, err)
+		}
+		return &udpTransport{conn: conn}, nil
+	case "tcp":
+		return newTCPTransport(u.Host), nil
+	case "serial":
+		options, err := parseSerialOptions(u.Query())
+		if err != nil {
+			return nil, err
+		}
+		return openSerial(u.Path, options)
+	}
+	return nil, fmt.Errorf("unknown transport %q", u.Scheme)
+}
+
+// udpTransport sends a datagram per packet, every datagram received is an
+// answer.
+type udpTransport struct {
+	conn *net.UDPConn
+}
+
+func (t *udpTransport) Send(packet []byte) error {
+	_, err := t.conn.Write(packet)
+	return err
+}
+
+func (t *udpTransport) Receive() ([]byte, error) {
+	buf := make([]byte, 65536)
+	n, err := t.conn.Read(buf)
+	return buf[:n], err
+}
+
+func (t *udpTransport) Close() error {
+	return t.conn.Close()
+}
+
+// tcpReconnectDelay is the time between attempts to reconnect a broken TCP
+// connection.
+const tcpReconnectDelay = time.Second
+
+// tcpTransport streams packets over a TCP connection, which is dialed on the
+// first send and again after it broke.
+type tcpTransport struct {
+	addr string
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	conn   net.Conn
+	reader *bufio.Scanner
+	closed bool
+}
+
+func newTCPTransport(addr string) *tcpTransport {
+	t := &tcpTransport{addr: addr}
+	t.cond = sync.NewCond(&t.mu)
+	return t
+}
+
+func (t *tcpTransport) Send(packet []byte) error {
+	conn, err := t.connect()
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write(packet); err != nil {
+		t.drop(conn)
+		return err
+	}
+	return nil
+}
+
+func (t *tcpTransport) connect() (net.Conn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return nil, net.ErrClosed
+	}
+	if t.conn != nil {
+		return t.conn, nil
+	}
+	conn, err := net.DialTimeout("tcp", t.addr, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial TCP %s: %w", t.addr, err)
+	}
+	t.conn, t.reader = conn, newPacketScanner(conn)
+	t.cond.Broadcast()
+	return conn, nil
+}
+
+// drop closes a broken connection, unless it was replaced already.
+func (t *tcpTransport) drop(conn net.Conn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.conn == conn {
+		_ = conn.Close()
+		t.conn, t.reader = nil, nil
+	}
+}
+
+func (t *tcpTransport) Receive() ([]byte, error) {
+	t.mu.Lock()
+	for t.conn == nil && !t.closed {
+		t.cond.Wait()
+	}
+	if t.closed {
+		t.mu.Unlock()
+		return nil, net.ErrClosed
+	}
+	conn, reader := t.conn, t.reader
+	t.mu.Unlock()
+
+	if reader.Scan() {
+		return reader.Bytes(), nil
+	}
+	t.mu.Lock()
+	closed := t.closed
+	t.mu.Unlock()
+	if closed {
+		return nil, net.ErrClosed
+	}
+
+	err := reader.Err()
+	if err == nil {
+		err = fmt.Errorf("connection to %s closed by the sign", t.addr)
+	}
+	t.drop(conn)
+	time.Sleep(tcpReconnectDelay)
+	return nil, err
+}
+
+func (t *tcpTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.closed = true
+	t.cond.Broadcast()
+	if t.conn != nil {
+		return t.conn.Close()
+	}
+	return nil
+}
+
+// newPacketScanner splits a byte stream into packets, see ScanPackets.
+func newPacketScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 4096), 1<<20)
+	scanner.Split(ScanPackets)
+	return scanner
+}
+
+// ScanPackets is a bufio.SplitFunc for streams without datagram boundaries,
+// like TCP and serial lines. Tokens are single acknowledge or reject bytes
+// and whole packets from start of header to end of transmission. Wake up NUL
+// bytes and noise between packets are skipped.
+//
+// Packets end at the first end of transmission following their body. Text
+// can't contain control characters, but the date set on the sign is binary,
+// its BCD bytes are skipped by their fixed length.
+func ScanPackets(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	start := bytes.IndexFunc(data, func(r rune) bool {
+		return r == rune(PacketStartOfHeader[0]) || r == rune(PacketAck[0]) || r == rune(PacketNak[0])
+	})
+	if start < 0 {
+		return len(data), nil, nil
+	}
+	if data[start] != PacketStartOfHeader[0] {
+		return start + 1, data[start : start+1], nil
+	}
+	if body, ok := binaryBody(data[start:]); ok && start+body <= len(data) {
+		if end := bytes.IndexByte(data[start+body:], ControlEnd[0]); end >= 0 {
+			end += start + body
+			return end + 1, data[start : end+1], nil
+		}
+	}
+	if atEOF {
+		return len(data), nil, nil
+	}
+	return start, nil, nil
+}
+
+// dateLength is the number of BCD bytes of the date function, see
+// DateFunction.
+const dateLength = 8
+
+// binaryBody returns the length of the packet's start up to which it can't
+// end: its header and command code, and the date of date functions, sent and
+// answered alike. It is false until enough of the packet arrived to tell.
+func binaryBody(packet []byte) (int, bool) {
+	// Start of header, type, address, start of text and command and function
+	// code.
+	const header = 7
+	if bytes.IndexByte(packet[:min(len(packet), header)], ControlEnd[0]) >= 0 {
+		// Too short for a date function.
+		return 0, true
+	}
+	if len(packet) < header {
+		return 0, false
+	}
+	if string(packet[header-2:header]) == CommandSpecialFunction+FunctionDate {
+		return header + dateLength, true
+	}
+	return header, true
+}
+
+// SerialOptions configures a serial line.
+type SerialOptions struct {
+	Baud int
+	// Parity is "none", "even" or "odd".
+	Parity   string
+	DataBits int
+	StopBits int
+	// RS485 lets the driver switch the direction of a half duplex RS-485
+	// transceiver, for adapters that don't do it themselves.
+	RS485 bool
+}
+
+func parseSerialOptions(query url.Values) (SerialOptions, error) {
+	options := SerialOptions{Baud: 9600, Parity: "none", DataBits: 8, StopBits: 1}
+
+	number := func(name string, value *int, valid ...int) error {
+		s := query.Get(name)
+		if s == "" {
+			return nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || (len(valid) > 0 && !slices.Contains(valid, n)) || n <= 0 {
+			return fmt.Errorf("invalid serial option %s=%q", name, s)
+		}
+		*value = n
+		return nil
+	}
+	if err := number("baud", &options.Baud); err != nil {
+		return options, err
+	}
+	if err := number("databits", &options.DataBits, 7, 8); err != nil {
+		return options, err
+	}
+	if err := number("stopbits", &options.StopBits, 1, 2); err != nil {
+		return options, err
+	}
+	if parity := query.Get("parity"); parity != "" {
+		switch parity {
+		case "none", "even", "odd":
+			options.Parity = parity
+		default:
+			return options, fmt.Errorf("invalid serial option parity=%q", parity)
+		}
+	}
+	if rs485 := query.Get("rs485"); rs485 != "" {
+		enabled, err := strconv.ParseBool(rs485)
+		if err != nil {
+			return options, fmt.Errorf("invalid serial option rs485=%q", rs485)
+		}
+		options.RS485 = enabled
+	}
+	return options, nil
+}
@@ -2,15 +2,24 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/b4ckspace/ledboard-v2/application"
+	"github.com/b4ckspace/ledboard-v2/emulator"
+	"github.com/b4ckspace/ledboard-v2/graphics"
 	"github.com/b4ckspace/ledboard-v2/ledboard"
 	"github.com/b4ckspace/ledboard-v2/mqttclient"
+	"github.com/b4ckspace/ledboard-v2/pixelfont"
+	"github.com/b4ckspace/ledboard-v2/screens"
 	"github.com/b4ckspace/ledboard-v2/utils"
 
 	"github.com/kelseyhightower/envconfig"
@@ -19,9 +28,73 @@ import (
 type Config struct {
 	Debug bool `envconfig:"DEBUG"`
 
-	Mode         string `envconfig:"MODE" required:"true"`
-	LedBoardHost string `envconfig:"LEDBOARD_HOST" required:"true"`
-	TimeZome     string `envconfig:"TZ" default:"Europe/Berlin"`
+	Mode     string `envconfig:"MODE" required:"true"`
+	TimeZome string `envconfig:"TZ" default:"Europe/Berlin"`
+
+	// LedBoardSigns lists the signs as "[name=]url[@TAA]", a bare "host[:port]" is reached over UDP.
+	LedBoardSigns []string `envconfig:"LEDBOARD_SIGNS"`
+	// LedBoardHost is the single sign reached over UDP the daemon drove before LedBoardSigns,
+	// deprecated. It is only used if LedBoardSigns is empty.
+	LedBoardHost string `envconfig:"LEDBOARD_HOST"`
+	// LedBoardGroups maps group names to space separated sign names, e.g. "hall:front back".
+	LedBoardGroups map[string]string `envconfig:"LEDBOARD_GROUPS"`
+	// LedBoardGroup selects the sign or group this daemon drives, all signs if empty.
+	LedBoardGroup string `envconfig:"LEDBOARD_GROUP"`
+
+	// LedBoardFonts maps custom font slots to font files or builtin fonts, e.g. "custom-3:compact-3x5".
+	LedBoardFonts map[string]string `envconfig:"LEDBOARD_FONTS"`
+
+	// LedBoardWidth and LedBoardHeight are the board's size in pixels, text is laid out to fit.
+	LedBoardWidth  int `envconfig:"LEDBOARD_WIDTH" default:"160"`
+	LedBoardHeight int `envconfig:"LEDBOARD_HEIGHT" default:"16"`
+
+	// LedBoardSendGapMs is the minimum time between two packets to a sign, LedBoardQueueSize
+	// the number of packets waiting for a sign before new ones are dropped.
+	LedBoardSendGapMs int `envconfig:"LEDBOARD_SEND_GAP_MS" default:"100"`
+	LedBoardQueueSize int `envconfig:"LEDBOARD_QUEUE_SIZE" default:"32"`
+
+	// LedBoardClockSyncMinutes is the interval the sign's clock is set in, it is also set
+	// when the sign comes online and after daylight saving time changes.
+	LedBoardClockSyncMinutes int `envconfig:"LEDBOARD_CLOCK_SYNC_MINUTES" default:"60"`
+
+	// LedBoardRecord is a capture file every datagram sent is appended to, with the MQTT
+	// message that caused it. Replay it with cmd/ledboard-replay.
+	LedBoardRecord string `envconfig:"LEDBOARD_RECORD"`
+
+	// LedBoardBrightnessSchedule sets the brightness over the day, e.g. "07:00=100,22:00=40".
+	LedBoardBrightnessSchedule []string `envconfig:"LEDBOARD_BRIGHTNESS_SCHEDULE"`
+	// LedBoardBrightnessEmpty is the brightness in percent while nobody is present.
+	LedBoardBrightnessEmpty int `envconfig:"LEDBOARD_BRIGHTNESS_EMPTY" default:"20"`
+	// LedBoardLuxTopic is the MQTT topic of an ambient light sensor, dimming the sign below
+	// LedBoardLuxFull down to LedBoardBrightnessMin percent.
+	LedBoardLuxTopic      string  `envconfig:"LEDBOARD_LUX_TOPIC"`
+	LedBoardLuxFull       float64 `envconfig:"LEDBOARD_LUX_FULL" default:"300"`
+	LedBoardBrightnessMin int     `envconfig:"LEDBOARD_BRIGHTNESS_MIN" default:"10"`
+
+	// LedBoardCommandTopic is the MQTT topic taking operator commands like "reset", see
+	// application.ManagementConfig. LedBoardDoorBellBeep is sounded when the door bell rings,
+	// as "frequency,duration,repeats", e.g. "120,300ms,2".
+	LedBoardCommandTopic string `envconfig:"LEDBOARD_COMMAND_TOPIC"`
+	LedBoardDoorBellBeep string `envconfig:"LEDBOARD_DOORBELL_BEEP"`
+	// LedBoardStateTopic is the MQTT topic the screen shown and when the sign is idle again
+	// are published to, see application.State.
+	LedBoardStateTopic string `envconfig:"LEDBOARD_STATE_TOPIC"`
+
+	// LedBoardPatternCalibration scales the modeled duration of patterns to what was measured
+	// on the sign, e.g. "radar-scan:1.5,scroll-up:0.8".
+	LedBoardPatternCalibration map[string]string `envconfig:"LEDBOARD_PATTERN_CALIBRATION"`
+
+	// LedBoardScreens is a template file defining the screens, see screens/screens.json. It is
+	// reloaded when it changes, checked every LedBoardScreensPollSeconds, and on SIGHUP.
+	LedBoardScreens            string `envconfig:"LEDBOARD_SCREENS"`
+	LedBoardScreensPollSeconds int    `envconfig:"LEDBOARD_SCREENS_POLL_SECONDS" default:"5"`
+	// LedBoardPictures maps picture file labels to images the screens show with {picture P},
+	// e.g. "G:/etc/ledboard/logo.png". The images are scaled down to fit the board.
+	LedBoardPictures map[string]string `envconfig:"LEDBOARD_PICTURES"`
+
+	// LedBoardRoutes is a routing table mapping MQTT topics to screens and actions, see
+	// application/routes.json for the default one.
+	LedBoardRoutes string `envconfig:"LEDBOARD_ROUTES"`
 
 	LedBoardPingIntervalSeconds int `envconfig:"LEDBOARD_PING_INTERVAL_SECONDS" default:"5"`
 
@@ -53,11 +126,71 @@ func main() {
 	}
 
 	// Initialize LED Board Client
-	ledBoardClient, err := ledboard.NewClient(config.LedBoardHost, 9520)
+	specs := config.LedBoardSigns
+	if config.LedBoardHost != "" {
+		if len(specs) == 0 {
+			slog.Warn("LEDBOARD_HOST is deprecated, use LEDBOARD_SIGNS", "host", config.LedBoardHost)
+			specs = []string{"udp://" + config.LedBoardHost}
+		} else {
+			slog.Warn("LEDBOARD_HOST is deprecated and ignored, LEDBOARD_SIGNS is set")
+		}
+	}
+	if len(specs) == 0 {
+		slog.Error("unable to parse environment", "error", "required key LEDBOARD_SIGNS missing value")
+		os.Exit(1)
+	}
+	var signs []ledboard.Sign
+	for _, spec := range specs {
+		sign, err := ledboard.ParseSign(spec)
+		if err != nil {
+			slog.Error("invalid sign configuration", "error", err)
+			os.Exit(1)
+		}
+		signs = append(signs, sign)
+	}
+	groups := make(map[string][]string)
+	for group, members := range config.LedBoardGroups {
+		groups[group] = strings.Fields(members)
+	}
+
+	queue := ledboard.QueueOptions{
+		Gap:  time.Duration(config.LedBoardSendGapMs) * time.Millisecond,
+		Size: config.LedBoardQueueSize,
+	}
+	ledBoardClient, err := ledboard.NewClient(signs, groups, queue)
 	if err != nil {
 		slog.Error("failed to connect to ledboard", "error", err)
 		os.Exit(1)
 	}
+	defer ledBoardClient.Close()
+	if config.LedBoardRecord != "" {
+		recorder, err := ledboard.NewRecorder(config.LedBoardRecord)
+		if err != nil {
+			slog.Error("unable to record datagrams", "error", err)
+			os.Exit(1)
+		}
+		defer recorder.Close()
+		ledBoardClient.Record(recorder)
+		slog.Info("recording datagrams", "file", config.LedBoardRecord)
+	}
+	if config.LedBoardGroup != "" {
+		ledBoardClient, err = ledBoardClient.Group(config.LedBoardGroup)
+		if err != nil {
+			slog.Error("invalid ledboard group", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	fonts, err := loadFonts(config.LedBoardFonts)
+	if err != nil {
+		slog.Error("invalid font configuration", "error", err)
+		os.Exit(1)
+	}
+	pictures, err := loadPictures(config.LedBoardPictures, config.LedBoardWidth, config.LedBoardHeight)
+	if err != nil {
+		slog.Error("invalid picture configuration", "error", err)
+		os.Exit(1)
+	}
 
 	// Initialize MQTT Client
 	mqttClient := mqttclient.NewClient()
@@ -72,11 +205,22 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Initialize PingProbe
-	pingProbe, err := utils.NewPingProbe(config.LedBoardHost, config.LedBoardPingIntervalSeconds)
-	if err != nil {
-		slog.Error("unable to start pingprobe", "error", err)
-		os.Exit(1)
+	// Initialize one PingProbe per probe address, signs on serial lines share one without
+	var pingProbes []*utils.PingProbe
+	probed := make(map[string]bool)
+	for _, sign := range ledBoardClient.Signs() {
+		address := sign.ProbeAddress()
+		if probed[address] {
+			continue
+		}
+		probed[address] = true
+
+		pingProbe, err := utils.NewPingProbe(address, config.LedBoardPingIntervalSeconds)
+		if err != nil {
+			slog.Error("unable to start pingprobe", "error", err)
+			os.Exit(1)
+		}
+		pingProbes = append(pingProbes, pingProbe)
 	}
 
 	// Listen for OS signals to gracefully shut down
@@ -89,20 +233,130 @@ func main() {
 		cancel()
 	}()
 
+	board := screens.Board{Width: config.LedBoardWidth, Height: config.LedBoardHeight}
+	screenSet := screens.NewScreens(board, fonts)
+	screenSet.UsePictures(slices.Collect(maps.Keys(pictures))...)
+	if config.LedBoardScreens != "" {
+		if err := screenSet.Load(config.LedBoardScreens); err != nil {
+			slog.Error("unable to load screens", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	timing := emulator.NewBoard(config.LedBoardWidth, config.LedBoardHeight)
+	timing.Fonts = fonts.Fonts()
+	timing.Pictures = pictures
+	if timing.Calibration, err = loadCalibration(config.LedBoardPatternCalibration); err != nil {
+		slog.Error("invalid pattern calibration", "error", err)
+		os.Exit(1)
+	}
+
+	routes, err := application.LoadRoutes(config.LedBoardRoutes, screenSet)
+	if err != nil {
+		slog.Error("unable to load routes", "error", err)
+		os.Exit(1)
+	}
+
+	schedule, err := application.ParseBrightnessSchedule(config.LedBoardBrightnessSchedule)
+	if err != nil {
+		slog.Error("invalid brightness schedule", "error", err)
+		os.Exit(1)
+	}
+	brightness := application.BrightnessConfig{
+		Schedule: schedule,
+		Empty:    config.LedBoardBrightnessEmpty,
+		LuxTopic: config.LedBoardLuxTopic,
+		LuxFull:  config.LedBoardLuxFull,
+		Min:      config.LedBoardBrightnessMin,
+	}
+
+	management := application.ManagementConfig{
+		CommandTopic: config.LedBoardCommandTopic,
+		StateTopic:   config.LedBoardStateTopic,
+	}
+	if config.LedBoardDoorBellBeep != "" {
+		beep, err := ledboard.ParseBeep(config.LedBoardDoorBellBeep)
+		if err != nil {
+			slog.Error("invalid door bell beep", "error", err)
+			os.Exit(1)
+		}
+		management.DoorBellBeep = &beep
+	}
+
 	var app *application.Application
 	switch config.Mode {
 	case string(application.DefaultMode):
 		fallthrough
 	case string(application.LasercutterMode):
-		app = application.NewApplication(ledBoardClient, mqttClient, pingProbe, application.Mode(config.Mode), location)
+		app = application.NewApplication(ledBoardClient, mqttClient, pingProbes, fonts, pictures, screenSet, timing, application.Mode(config.Mode), location,
+			time.Duration(config.LedBoardClockSyncMinutes)*time.Minute, brightness, management, routes)
 	default:
 		slog.Error("unknown configuration mode", "mode", config.Mode)
 		os.Exit(1)
 	}
 
+	// Reload the screen templates on SIGHUP
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go screenSet.Watch(ctx, time.Duration(config.LedBoardScreensPollSeconds)*time.Second, reload, app.ScreensChanged)
+
 	err = app.Run(ctx)
 	if err != nil {
 		slog.Error("Unable to run", "error", err)
 		os.Exit(1)
 	}
 }
+
+// loadFonts assigns the configured fonts to their custom font slots.
+func loadFonts(config map[string]string) (*ledboard.FontSet, error) {
+	fonts := ledboard.NewFontSet()
+	for slotName, source := range config {
+		slot, err := ledboard.ParseFont(slotName)
+		if err != nil {
+			return nil, err
+		}
+		font, err := pixelfont.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		if err := fonts.Assign(slot, font); err != nil {
+			return nil, err
+		}
+	}
+	return fonts, nil
+}
+
+// loadPictures converts the configured images into pictures fitting the
+// board, by label.
+func loadPictures(config map[string]string, width, height int) (map[string]*ledboard.Picture, error) {
+	pictures := make(map[string]*ledboard.Picture)
+	for label, path := range config {
+		img, err := graphics.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		picture, err := graphics.Convert(img, graphics.Options{Width: width, Height: height})
+		if err != nil {
+			return nil, fmt.Errorf("picture %s: %w", label, err)
+		}
+		pictures[label] = picture
+	}
+	return pictures, application.CheckPictures(pictures)
+}
+
+// loadCalibration parses the factors scaling the duration of patterns.
+func loadCalibration(config map[string]string) (map[ledboard.Pattern]float64, error) {
+	calibration := make(map[ledboard.Pattern]float64)
+	for name, value := range config {
+		pattern, err := ledboard.ParsePattern(name)
+		if err != nil {
+			return nil, err
+		}
+		factor, err := strconv.ParseFloat(value, 64)
+		if err != nil || factor <= 0 {
+			return nil, fmt.Errorf("pattern %s: invalid factor %q", name, value)
+		}
+		calibration[pattern] = factor
+	}
+	return calibration, nil
+}
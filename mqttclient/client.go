@@ -52,6 +52,20 @@ func (c *Client) Subscribe(topic string, handler mqtt.MessageHandler) error {
 	return nil
 }
 
+// Publish publishes the payload to the specified MQTT topic. Retained
+// messages are handed to clients subscribing later too.
+func (c *Client) Publish(topic string, retained bool, payload []byte) error {
+	if c.mqttClient == nil || !c.mqttClient.IsConnected() {
+		return fmt.Errorf("mqtt client not connected, cannot publish")
+	}
+	token := c.mqttClient.Publish(topic, 0, retained, payload)
+	token.Wait()
+	if token.Error() != nil {
+		return fmt.Errorf("failed to publish to topic %s: %w", topic, token.Error())
+	}
+	return nil
+}
+
 // Disconnect disconnects the MQTT client from the broker.
 func (c *Client) Disconnect() {
 	if c.mqttClient != nil && c.mqttClient.IsConnected() {
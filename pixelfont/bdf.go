@@ -0,0 +1,157 @@
+package pixelfont
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ParseBDF reads a font in the Glyph Bitmap Distribution Format. Glyph
+// encodings are taken as Unicode code points, which holds for ISO10646 and
+// ISO8859-1 fonts. Glyphs without an encoding are skipped.
+func ParseBDF(r io.Reader) (*Font, error) {
+	font := &Font{Glyphs: make(map[rune]Glyph)}
+	var ascent, descent int
+	var boxHeight, boxOffset int
+
+	// State of the glyph being read.
+	var (
+		encoding              = -1
+		advance               int
+		width, height, dx, dy int
+		bitmap                []string
+		inBitmap              bool
+	)
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		if inBitmap && fields[0] != "ENDCHAR" {
+			bitmap = append(bitmap, fields[0])
+			continue
+		}
+
+		ints := func(n int) ([]int, error) {
+			if len(fields) < n+1 {
+				return nil, fmt.Errorf("line %d: %s needs %d values", line, fields[0], n)
+			}
+			values := make([]int, n)
+			for i := range values {
+				v, err := strconv.Atoi(fields[i+1])
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid %s: %w", line, fields[0], err)
+				}
+				values[i] = v
+			}
+			return values, nil
+		}
+
+		switch fields[0] {
+		case "FONT":
+			font.Name = strings.Join(fields[1:], " ")
+		case "FONTBOUNDINGBOX":
+			v, err := ints(4)
+			if err != nil {
+				return nil, err
+			}
+			boxHeight, boxOffset = v[1], v[3]
+		case "FONT_ASCENT":
+			v, err := ints(1)
+			if err != nil {
+				return nil, err
+			}
+			ascent = v[0]
+		case "FONT_DESCENT":
+			v, err := ints(1)
+			if err != nil {
+				return nil, err
+			}
+			descent = v[0]
+		case "STARTCHAR":
+			encoding, advance, width, height, dx, dy, bitmap = -1, 0, 0, 0, 0, 0, nil
+		case "ENCODING":
+			v, err := ints(1)
+			if err != nil {
+				return nil, err
+			}
+			encoding = v[0]
+		case "DWIDTH":
+			v, err := ints(1)
+			if err != nil {
+				return nil, err
+			}
+			advance = v[0]
+		case "BBX":
+			v, err := ints(4)
+			if err != nil {
+				return nil, err
+			}
+			width, height, dx, dy = v[0], v[1], v[2], v[3]
+		case "BITMAP":
+			inBitmap = true
+		case "ENDCHAR":
+			inBitmap = false
+			if encoding < 0 {
+				continue
+			}
+			if ascent == 0 && descent == 0 {
+				ascent, descent = boxHeight+boxOffset, -boxOffset
+			}
+			font.Height = ascent + descent
+			glyph, err := bdfGlyph(bitmap, font.Height, ascent, advance, width, height, dx, dy)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: glyph %d: %w", line, encoding, err)
+			}
+			font.Glyphs[rune(encoding)] = glyph
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(font.Glyphs) == 0 {
+		return nil, fmt.Errorf("font has no glyphs")
+	}
+	return font, nil
+}
+
+// bdfGlyph places the bitmap of a BDF glyph into a cell of the font's height,
+// the baseline sitting ascent rows below the top.
+func bdfGlyph(bitmap []string, cellHeight, ascent, advance, width, height, dx, dy int) (Glyph, error) {
+	if advance <= 0 {
+		advance = dx + width
+	}
+	if advance > MaxWidth {
+		return Glyph{}, fmt.Errorf("glyph is wider than %d pixels", MaxWidth)
+	}
+	if len(bitmap) != height {
+		return Glyph{}, fmt.Errorf("bitmap has %d rows, expected %d", len(bitmap), height)
+	}
+
+	glyph := Glyph{Width: advance, Rows: make([]uint32, cellHeight)}
+	top := ascent - dy - height
+	for row, hex := range bitmap {
+		y := top + row
+		if y < 0 || y >= cellHeight {
+			continue
+		}
+		bits, err := strconv.ParseUint(hex, 16, 64)
+		if err != nil {
+			return Glyph{}, fmt.Errorf("invalid bitmap row %q", hex)
+		}
+		// Rows are padded to full bytes, the leftmost pixel is the highest bit.
+		for x := 0; x < width; x++ {
+			if bits&(1<<(len(hex)*4-1-x)) != 0 && dx+x >= 0 && dx+x < advance {
+				glyph.Rows[y] |= 1 << (dx + x)
+			}
+		}
+	}
+	return glyph, nil
+}
@@ -0,0 +1,82 @@
+// Package pixelfont reads bitmap fonts, either BDF or a simple text format,
+// to be uploaded into the custom font slots of the LED board.
+package pixelfont
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MaxWidth is the widest glyph a font may hold.
+const MaxWidth = 32
+
+// Glyph is the bitmap of a single character. Bit x of a row is the pixel in
+// column x, counted from the left.
+type Glyph struct {
+	Width int
+	Rows  []uint32
+}
+
+// Pixel reports whether the pixel at x, y is lit.
+func (g Glyph) Pixel(x, y int) bool {
+	if x < 0 || x >= g.Width || y < 0 || y >= len(g.Rows) {
+		return false
+	}
+	return g.Rows[y]&(1<<x) != 0
+}
+
+// Font is a set of glyphs sharing the same height.
+type Font struct {
+	Name   string
+	Height int
+	Glyphs map[rune]Glyph
+}
+
+// Load reads a font file, BDF if its name ends in .bdf, the text format otherwise.
+func Load(path string) (*Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read font: %w", err)
+	}
+
+	var font *Font
+	if strings.EqualFold(filepath.Ext(path), ".bdf") {
+		font, err = ParseBDF(bytes.NewReader(data))
+	} else {
+		font, err = ParseText(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse font %s: %w", path, err)
+	}
+	if font.Name == "" {
+		font.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return font, nil
+}
+
+//go:embed compact-3x5.txt
+var compact string
+
+// Open returns the builtin font of the given name, like "compact-3x5", or
+// loads the font file at that path.
+func Open(source string) (*Font, error) {
+	switch source {
+	case "compact-3x5":
+		return Compact(), nil
+	}
+	return Load(source)
+}
+
+// Compact returns a 3x5 pixel font with umlauts and a few symbols, small
+// enough to show three lines on a 16 pixel high board.
+func Compact() *Font {
+	font, err := ParseText(strings.NewReader(compact))
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded font: %s", err))
+	}
+	return font
+}
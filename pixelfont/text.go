@@ -0,0 +1,137 @@
+package pixelfont
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParseText reads the text font format:
+//
+//	// comment
+//	font compact-3x5
+//	height 5
+//	spacing 1
+//
+//	glyph A
+//	.#.
+//	#.#
+//	###
+//	#.#
+//	#.#
+//
+// A glyph is named by the character itself or by its code point, like
+// U+0020 for the space. It is followed by height rows of '#' for lit and '.'
+// for dark pixels, all of the same width. Spacing empty columns are added to
+// the right of every glyph, 1 by default.
+func ParseText(r io.Reader) (*Font, error) {
+	font := &Font{Glyphs: make(map[rune]Glyph)}
+	spacing := 1
+
+	var current rune
+	var glyph *Glyph
+	finish := func(line int) error {
+		if glyph == nil {
+			return nil
+		}
+		if len(glyph.Rows) != font.Height {
+			return fmt.Errorf("line %d: glyph %q has %d rows, expected %d", line, current, len(glyph.Rows), font.Height)
+		}
+		glyph.Width += spacing
+		font.Glyphs[current] = *glyph
+		glyph = nil
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "//") {
+			continue
+		}
+
+		if glyph != nil && strings.Trim(text, "#.") == "" {
+			if len(glyph.Rows) == 0 {
+				glyph.Width = len(text)
+			}
+			if len(text) != glyph.Width {
+				return nil, fmt.Errorf("line %d: row has %d pixels, expected %d", line, len(text), glyph.Width)
+			}
+			if len(text)+spacing > MaxWidth {
+				return nil, fmt.Errorf("line %d: glyph is wider than %d pixels", line, MaxWidth)
+			}
+			var row uint32
+			for x, c := range text {
+				if c == '#' {
+					row |= 1 << x
+				}
+			}
+			glyph.Rows = append(glyph.Rows, row)
+			continue
+		}
+
+		if err := finish(line); err != nil {
+			return nil, err
+		}
+		keyword, value, _ := strings.Cut(text, " ")
+		value = strings.TrimSpace(value)
+		switch keyword {
+		case "font":
+			font.Name = value
+		case "height":
+			height, err := strconv.Atoi(value)
+			if err != nil || height <= 0 {
+				return nil, fmt.Errorf("line %d: invalid height %q", line, value)
+			}
+			font.Height = height
+		case "spacing":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("line %d: invalid spacing %q", line, value)
+			}
+			spacing = n
+		case "glyph":
+			if font.Height == 0 {
+				return nil, fmt.Errorf("line %d: glyph before height", line)
+			}
+			c, err := parseRune(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			current, glyph = c, &Glyph{}
+		default:
+			return nil, fmt.Errorf("line %d: unknown keyword %q", line, keyword)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := finish(line); err != nil {
+		return nil, err
+	}
+	if len(font.Glyphs) == 0 {
+		return nil, fmt.Errorf("font has no glyphs")
+	}
+	return font, nil
+}
+
+// parseRune reads a glyph name, a single character or U+XXXX.
+func parseRune(name string) (rune, error) {
+	if hex, ok := strings.CutPrefix(name, "U+"); ok {
+		code, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid code point %q", name)
+		}
+		return rune(code), nil
+	}
+	c, size := utf8.DecodeRuneInString(name)
+	if c == utf8.RuneError || size != len(name) {
+		return 0, fmt.Errorf("glyph name %q is not a single character", name)
+	}
+	return c, nil
+}
@@ -0,0 +1,190 @@
+package screens
+
+import (
+	"strings"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+)
+
+// Board is the pixel geometry of the LED board screens are laid out for.
+type Board struct {
+	Width  int
+	Height int
+}
+
+// DefaultBoard is the geometry of the backspace LED board.
+var DefaultBoard = Board{Width: 160, Height: 16}
+
+const (
+	// maxPages is the most pages a text is split into, longer texts scroll.
+	maxPages = 4
+
+	// minPageSeconds is the shortest time a page is shown.
+	minPageSeconds = 3
+)
+
+// messageFonts are the fonts user supplied text is shown in, largest first.
+var messageFonts = []ledboard.Font{
+	ledboard.FontNormal16x9,
+	ledboard.FontNormal14x8,
+	ledboard.FontNormal7x6,
+	ledboard.FontNormal5x5,
+}
+
+// fallbackFont shows text none of the fonts asked for can, like custom fonts
+// that aren't loaded. It is the smallest builtin font.
+const fallbackFont = ledboard.FontNormal5x5
+
+// Layout places a text on the board.
+type Layout struct {
+	Font ledboard.Font
+	// Pages holds the lines shown at once. A scrolling layout has a single
+	// page with a single line, wider than the board.
+	Pages  [][]string
+	Scroll bool
+}
+
+// Fit lays out the text in the first of the fonts that shows it on a single
+// page, on one line or wrapped at spaces. If none does, the text is split into
+// pages of the last font, or scrolls in it when that takes more than maxPages
+// pages. Without a font fitting the board the text scrolls in fallbackFont.
+// Text is expected in the sign's charset, custom fonts are skipped.
+func (b Board) Fit(text string, fonts ...ledboard.Font) Layout {
+	return b.fit(fonts, func(font ledboard.Font) (string, ledboard.FontMetrics, bool) {
+		metrics, ok := font.Metrics()
+		return text, metrics, ok
+	})
+}
+
+// fit is Fit with the text in the character codes of each font and its
+// metrics returned by measure, false for fonts to skip.
+func (b Board) fit(fonts []ledboard.Font, measure func(ledboard.Font) (string, ledboard.FontMetrics, bool)) Layout {
+	var layout Layout
+	var text string
+	for _, font := range fonts {
+		encoded, metrics, ok := measure(font)
+		if !ok || metrics.Height > b.Height {
+			continue
+		}
+		text = encoded
+		lines := b.wrap(text, metrics)
+		perPage := b.Height / metrics.Height
+
+		layout = Layout{Font: font}
+		for len(lines) > 0 {
+			n := min(perPage, len(lines))
+			layout.Pages = append(layout.Pages, lines[:n])
+			lines = lines[n:]
+		}
+		if len(layout.Pages) == 1 {
+			return layout
+		}
+	}
+
+	if layout.Font == "" {
+		// No font fits the board, the text scrolls rather than being lost.
+		layout.Font = fallbackFont
+		text, _, _ = measure(fallbackFont)
+	}
+	if len(layout.Pages) > maxPages || len(layout.Pages) == 0 {
+		layout.Pages = [][]string{{strings.Join(strings.Fields(text), " ")}}
+		layout.Scroll = true
+	}
+	return layout
+}
+
+// wrap breaks the text into lines fitting the board's width, at spaces if
+// possible. A text that fits as a whole is kept as it is.
+func (b Board) wrap(text string, metrics ledboard.FontMetrics) []string {
+	if metrics.TextWidth(text) <= b.Width {
+		return []string{text}
+	}
+
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if metrics.TextWidth(candidate) <= b.Width {
+			line = candidate
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+
+		// Words wider than the board are broken wherever the line is full.
+		for metrics.TextWidth(word) > b.Width {
+			n := 1
+			for n < len(word) && metrics.TextWidth(word[:n+1]) <= b.Width {
+				n++
+			}
+			lines = append(lines, word[:n])
+			word = word[n:]
+		}
+		line = word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// textStyle is how addText shows a text. Empty fields keep the current
+// setting of the screen.
+type textStyle struct {
+	// fonts to choose from, messageFonts if empty.
+	fonts      []ledboard.Font
+	color      ledboard.FontColor
+	patternIn  ledboard.Pattern
+	patternOut ledboard.Pattern
+	// seconds is the time the whole text is shown, shared by its pages.
+	seconds int
+}
+
+// addText lays the text out on the board, measured in the builtin or custom
+// fonts, and adds a frame for each page. It returns the font chosen.
+func (s *Screens) addText(screen *ledboard.Screen, text string, style textStyle) ledboard.Font {
+	fonts := style.fonts
+	if len(fonts) == 0 {
+		fonts = messageFonts
+	}
+	layout := s.board.fit(fonts, func(font ledboard.Font) (string, ledboard.FontMetrics, bool) {
+		metrics, ok := s.fonts.Metrics(font)
+		return s.text(font, text), metrics, ok
+	})
+
+	seconds := style.seconds
+	if len(layout.Pages) > 1 {
+		seconds = max(seconds/len(layout.Pages), minPageSeconds)
+	}
+	for _, page := range layout.Pages {
+		frame := screen.AddFrame().Font(layout.Font)
+		if style.color != "" {
+			frame.Color(style.color)
+		}
+
+		patternIn, patternOut := style.patternIn, style.patternOut
+		if layout.Scroll {
+			patternIn, patternOut = ledboard.PatternScrollLeft, ledboard.PatternScrollLeft
+			seconds = 1
+		}
+		if patternIn != "" {
+			frame.PatternIn(patternIn)
+		}
+		if patternOut != "" {
+			frame.PatternOut(patternOut)
+		}
+
+		for i, line := range page {
+			if i > 0 {
+				frame.LineFeed()
+			}
+			frame.Text(line)
+		}
+		frame.PauseSeconds(seconds)
+	}
+	return layout.Font
+}
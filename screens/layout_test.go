@@ -0,0 +1,157 @@
+package screens
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+)
+
+func TestFit(t *testing.T) {
+	fox := "The quick brown fox jumps over the lazy dog"
+	tests := []struct {
+		name  string
+		board Board
+		text  string
+		fonts []ledboard.Font
+		want  Layout
+	}{
+		{
+			name:  "largest font",
+			board: DefaultBoard,
+			text:  "Hello",
+			fonts: messageFonts,
+			want:  Layout{Font: ledboard.FontNormal16x9, Pages: [][]string{{"Hello"}}},
+		},
+		{
+			name:  "too wide for the largest font",
+			board: DefaultBoard,
+			text:  "abcdefghijklmnopqr",
+			fonts: messageFonts,
+			want:  Layout{Font: ledboard.FontNormal14x8, Pages: [][]string{{"abcdefghijklmnopqr"}}},
+		},
+		{
+			name:  "wrapped onto two lines",
+			board: DefaultBoard,
+			text:  fox,
+			fonts: messageFonts,
+			want:  Layout{Font: ledboard.FontNormal7x6, Pages: [][]string{{"The quick brown fox jumps", "over the lazy dog"}}},
+		},
+		{
+			name:  "pages of the last font",
+			board: DefaultBoard,
+			text:  fox + " and " + fox + " again",
+			fonts: []ledboard.Font{ledboard.FontNormal16x9, ledboard.FontNormal7x6},
+			want: Layout{Font: ledboard.FontNormal7x6, Pages: [][]string{
+				{"The quick brown fox jumps", "over the lazy dog and The"},
+				{"quick brown fox jumps over", "the lazy dog again"},
+			}},
+		},
+		{
+			name:  "scrolls beyond maxPages",
+			board: DefaultBoard,
+			text:  strings.Repeat(fox+"  ", 10),
+			fonts: messageFonts,
+			want: Layout{
+				Font:   ledboard.FontNormal5x5,
+				Pages:  [][]string{{strings.TrimSpace(strings.Repeat(fox+" ", 10))}},
+				Scroll: true,
+			},
+		},
+		{
+			name:  "first font fitting",
+			board: DefaultBoard,
+			text:  "Hi",
+			fonts: []ledboard.Font{ledboard.FontNormal7x6, ledboard.FontNormal16x9},
+			want:  Layout{Font: ledboard.FontNormal7x6, Pages: [][]string{{"Hi"}}},
+		},
+		{
+			name:  "empty text",
+			board: DefaultBoard,
+			fonts: messageFonts,
+			want:  Layout{Font: ledboard.FontNormal16x9, Pages: [][]string{{""}}},
+		},
+		{
+			name:  "custom font not loaded",
+			board: DefaultBoard,
+			text:  "Hello  world",
+			fonts: []ledboard.Font{ledboard.FontCustom3},
+			want:  Layout{Font: fallbackFont, Pages: [][]string{{"Hello world"}}, Scroll: true},
+		},
+		{
+			name:  "fonts taller than the board",
+			board: Board{Width: 160, Height: 7},
+			text:  "Hello",
+			fonts: []ledboard.Font{ledboard.FontNormal16x9, ledboard.FontNormal14x8},
+			want:  Layout{Font: fallbackFont, Pages: [][]string{{"Hello"}}, Scroll: true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.board.Fit(test.text, test.fonts...); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Fit(%q) = %+v, want %+v", test.text, got, test.want)
+			}
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	metrics, _ := ledboard.FontNormal7x6.Metrics()
+	// 26 characters of 6 pixels fit the 160 pixels of the board.
+	full := strings.Repeat("x", 26)
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", []string{""}},
+		{"short", []string{"short"}},
+		{"keeps  its   spaces", []string{"keeps  its   spaces"}},
+		{full, []string{full}},
+		{full + "y", []string{full, "y"}},
+		{"ab " + full + "yz cd", []string{"ab", full, "yz cd"}},
+		{"one two three four five six seven", []string{"one two three four five", "six seven"}},
+		{"  leading and trailing spaces of a long line  ", []string{"leading and trailing", "spaces of a long line"}},
+		{strings.Repeat("y", 60), []string{strings.Repeat("y", 26), strings.Repeat("y", 26), strings.Repeat("y", 8)}},
+	}
+	for _, test := range tests {
+		if got := DefaultBoard.wrap(test.text, metrics); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("wrap(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestAddTextPages(t *testing.T) {
+	s := NewScreens(DefaultBoard, nil)
+	screen := ledboard.NewScreen()
+	text := "The quick brown fox jumps over the lazy dog and again over the lazy dog"
+	style := textStyle{fonts: []ledboard.Font{ledboard.FontNormal7x6}, color: ledboard.FontColorRed, seconds: 12}
+	if font := s.addText(screen, text, style); font != ledboard.FontNormal7x6 {
+		t.Errorf("font = %s, want %s", font, ledboard.FontNormal7x6)
+	}
+	// The pages share the time, each shown at least minPageSeconds.
+	want := ledboard.NewScreen()
+	want.AddFrame().Font(ledboard.FontNormal7x6).Color(ledboard.FontColorRed).
+		Text("The quick brown fox jumps").LineFeed().Text("over the lazy dog and").PauseSeconds(6)
+	want.AddFrame().Font(ledboard.FontNormal7x6).Color(ledboard.FontColorRed).
+		Text("again over the lazy dog").PauseSeconds(6)
+	if !reflect.DeepEqual(screen, want) {
+		t.Errorf("screen\n%s, want\n%s", screen, want)
+	}
+}
+
+func TestAddTextWithoutFont(t *testing.T) {
+	s := NewScreens(DefaultBoard, nil)
+	screen := ledboard.NewScreen()
+	font := s.addText(screen, "Hello", textStyle{fonts: []ledboard.Font{ledboard.FontCustom3}, seconds: 5})
+	if font != fallbackFont {
+		t.Errorf("font = %s, want %s", font, fallbackFont)
+	}
+	if len(screen.Frames) != 1 {
+		t.Fatalf("%d frames, want 1", len(screen.Frames))
+	}
+	if _, err := screen.Encode(); err != nil {
+		t.Error(err)
+	}
+}
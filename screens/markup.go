@@ -0,0 +1,288 @@
+package screens
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+)
+
+// escape turns text into markup showing it as is.
+func escape(text string) string {
+	return strings.ReplaceAll(text, "{", "{{")
+}
+
+// markup builds screens from their markup, plain text and commands in braces like
+// "{color red}ALARM{pause 4}". A literal brace is written twice, "{{".
+//
+//	{font normal-7x6}        font of the following text
+//	{color green}            font color, {background black} background color
+//	{flash on}, {flash off}  flashing text
+//	{in scroll-up}           pattern showing the frame, {out ...} hiding it
+//	{speed fast}             speed of the patterns
+//	{align left}             horizontal alignment, {valign top} vertical
+//	{special hh}             value the sign fills in, like the hour
+//	{variable M}             value of the string file M
+//	{picture P}              picture file P
+//	{nl}                     line feed, {half} half space
+//	{pause 4}                hold the frame for seconds, {pause 400ms} for milliseconds
+//	{frame}                  start a new frame with the following content
+//	{fit seconds=30 color=green in=move-up out=move-left fonts=normal-7x6}text{/fit}
+//	                         lay the text out on the board in frames of their own
+//
+// Text is transliterated to the sign's charset, or mapped to the glyphs of the
+// custom font in effect.
+type markup struct {
+	screens *Screens
+	screen  *ledboard.Screen
+	frame   *ledboard.Frame
+	// font is the font in effect, it carries over to the following frames
+	// like on the sign.
+	font ledboard.Font
+}
+
+// parseMarkup builds the screen the markup describes.
+func (s *Screens) parseMarkup(source string) (*ledboard.Screen, error) {
+	m := &markup{screens: s, screen: ledboard.NewScreen()}
+
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			m.current().Text(m.screens.text(m.font, text.String()))
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(source); {
+		if strings.HasPrefix(source[i:], "{{") {
+			text.WriteByte('{')
+			i += 2
+			continue
+		}
+		if source[i] != '{' {
+			text.WriteByte(source[i])
+			i++
+			continue
+		}
+
+		end := strings.IndexByte(source[i:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("offset %d: unterminated command", i)
+		}
+		command := source[i+1 : i+end]
+		i += end + 1
+		flush()
+
+		if name, _, _ := strings.Cut(command, " "); name == "fit" {
+			body, n, err := fitBody(source[i:])
+			if err != nil {
+				return nil, fmt.Errorf("offset %d: %w", i, err)
+			}
+			i += n
+			if err := m.fit(command, body); err != nil {
+				return nil, fmt.Errorf("{%s}: %w", command, err)
+			}
+			continue
+		}
+		if err := m.command(command); err != nil {
+			return nil, fmt.Errorf("{%s}: %w", command, err)
+		}
+	}
+	flush()
+
+	if len(m.screen.Frames) == 0 {
+		return nil, errors.New("screen is empty")
+	}
+	if _, err := m.screen.Encode(); err != nil {
+		return nil, err
+	}
+	return m.screen, nil
+}
+
+// current returns the frame content goes to, starting one if needed.
+func (m *markup) current() *ledboard.Frame {
+	if m.frame == nil {
+		m.frame = m.screen.AddFrame()
+	}
+	return m.frame
+}
+
+func (m *markup) command(command string) error {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return errors.New("empty command")
+	}
+	name, args := fields[0], fields[1:]
+
+	switch name {
+	case "nl", "half", "frame":
+		if len(args) != 0 {
+			return errors.New("takes no argument")
+		}
+		switch name {
+		case "nl":
+			m.current().LineFeed()
+		case "half":
+			m.current().HalfSpace()
+		case "frame":
+			m.frame = nil
+		}
+		return nil
+	}
+
+	if len(args) != 1 {
+		return errors.New("takes a single argument")
+	}
+	arg := args[0]
+	var err error
+	switch name {
+	case "font":
+		var font ledboard.Font
+		if font, err = ledboard.ParseFont(arg); err == nil {
+			m.current().Font(font)
+			m.font = font
+		}
+	case "color":
+		var color ledboard.FontColor
+		if color, err = ledboard.ParseFontColor(arg); err == nil {
+			m.current().Color(color)
+		}
+	case "background":
+		var color ledboard.BackgroundColor
+		if color, err = ledboard.ParseBackgroundColor(arg); err == nil {
+			m.current().Background(color)
+		}
+	case "flash":
+		if arg != "on" && arg != "off" {
+			return errors.New("expected on or off")
+		}
+		m.current().Flash(arg == "on")
+	case "in", "out":
+		var pattern ledboard.Pattern
+		if pattern, err = ledboard.ParsePattern(arg); err == nil {
+			if name == "in" {
+				m.current().PatternIn(pattern)
+			} else {
+				m.current().PatternOut(pattern)
+			}
+		}
+	case "speed":
+		var speed ledboard.Speed
+		if speed, err = ledboard.ParseSpeed(arg); err == nil {
+			m.current().Speed(speed)
+		}
+	case "align":
+		var align ledboard.HorizontalAlign
+		if align, err = ledboard.ParseHorizontalAlign(arg); err == nil {
+			m.current().AlignHorizontal(align)
+		}
+	case "valign":
+		var align ledboard.VerticalAlign
+		if align, err = ledboard.ParseVerticalAlign(arg); err == nil {
+			m.current().AlignVertical(align)
+		}
+	case "special":
+		var special ledboard.Special
+		if special, err = ledboard.ParseSpecial(arg); err == nil {
+			m.current().Special(special)
+		}
+	case "variable":
+		m.current().Variable(arg)
+	case "picture":
+		if m.screens.pictures != nil && !slices.Contains(m.screens.pictures, arg) {
+			return errors.New("picture file is not uploaded")
+		}
+		m.current().Picture(arg)
+	case "pause":
+		return m.pause(arg)
+	default:
+		return errors.New("unknown command")
+	}
+	return err
+}
+
+func (m *markup) pause(arg string) error {
+	if strings.HasSuffix(arg, "ms") {
+		d, err := time.ParseDuration(arg)
+		if err != nil || d < 0 || d > 9999*time.Millisecond {
+			return errors.New("expected 0ms to 9999ms")
+		}
+		m.current().PauseMilliseconds(int(d.Milliseconds()))
+		return nil
+	}
+	seconds, err := strconv.Atoi(arg)
+	if err != nil || seconds < 0 || seconds > 9999 {
+		return errors.New("expected 0 to 9999 seconds")
+	}
+	m.current().PauseSeconds(seconds)
+	return nil
+}
+
+// fitBody returns the unescaped text up to {/fit} and how much of the
+// markup it took.
+func fitBody(source string) (string, int, error) {
+	var text strings.Builder
+	for i := 0; i < len(source); {
+		switch {
+		case strings.HasPrefix(source[i:], "{{"):
+			text.WriteByte('{')
+			i += 2
+		case strings.HasPrefix(source[i:], "{/fit}"):
+			return text.String(), i + len("{/fit}"), nil
+		case source[i] == '{':
+			return "", 0, errors.New("commands are not allowed in {fit}")
+		default:
+			text.WriteByte(source[i])
+			i++
+		}
+	}
+	return "", 0, errors.New("missing {/fit}")
+}
+
+// fit lays out the text in frames of its own, the options are key=value
+// pairs of the command.
+func (m *markup) fit(command, text string) error {
+	var style textStyle
+	for _, option := range strings.Fields(command)[1:] {
+		key, value, ok := strings.Cut(option, "=")
+		if !ok {
+			return fmt.Errorf("option %q: expected key=value", option)
+		}
+		var err error
+		switch key {
+		case "seconds":
+			style.seconds, err = strconv.Atoi(value)
+			if err == nil && (style.seconds < 0 || style.seconds > 9999) {
+				err = errors.New("out of range")
+			}
+		case "color":
+			style.color, err = ledboard.ParseFontColor(value)
+		case "in":
+			style.patternIn, err = ledboard.ParsePattern(value)
+		case "out":
+			style.patternOut, err = ledboard.ParsePattern(value)
+		case "fonts":
+			for _, name := range strings.Split(value, ",") {
+				font, ferr := ledboard.ParseFont(name)
+				if ferr != nil {
+					err = ferr
+					break
+				}
+				style.fonts = append(style.fonts, font)
+			}
+		default:
+			err = errors.New("unknown option")
+		}
+		if err != nil {
+			return fmt.Errorf("option %q: %w", option, err)
+		}
+	}
+
+	m.font = m.screens.addText(m.screen, text, style)
+	m.frame = nil
+	return nil
+}
@@ -1,274 +1,127 @@
 package screens
 
 import (
-	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/b4ckspace/ledboard-v2/charset"
 	"github.com/b4ckspace/ledboard-v2/ledboard"
-	"github.com/b4ckspace/ledboard-v2/utils"
 )
 
-// Screens represents the main screens manager
+// Labels of the string files the idle screen shows. Whoever stores the idle
+// screen allocates and writes them, see Status.
+const (
+	MemberCountString = "M"
+	StatusString      = "W"
+)
+
+// Screens represents the main screens manager. The screens are built from
+// templates, see Load.
 type Screens struct {
-	// Add fields for managing different screens
+	board Board
+	fonts *ledboard.FontSet
+	// pictures are the labels of the picture files templates may show, any
+	// if nil. See UsePictures.
+	pictures []string
+
+	defaults  *Templates
+	templates atomic.Pointer[Templates]
+
+	// mu guards the template file being watched.
+	mu      sync.Mutex
+	path    string
+	modTime time.Time
 }
 
-// NewScreens creates a new Screens instance
-func NewScreens() *Screens {
-	return &Screens{}
+// NewScreens creates a new Screens instance laying out text for the board,
+// using the default templates. Text in the custom fonts of the set, which
+// may be nil, is shown in their glyphs.
+func NewScreens(board Board, fonts *ledboard.FontSet) *Screens {
+	if fonts == nil {
+		fonts = ledboard.NewFontSet()
+	}
+	s := &Screens{board: board, fonts: fonts}
+	defaults, err := s.parseTemplates(defaultTemplates)
+	if err != nil {
+		panic(err)
+	}
+	s.defaults = defaults
+	s.templates.Store(defaults)
+	return s
 }
 
-// Alarm generates the command for the Alarm screen.
-func (s *Screens) Alarm(message string) string {
-	var cmd string
-
-	cmd += ledboard.ControlPatternIn + ledboard.PatternRadarScan
-
-	cmd += ledboard.FontNormal16x9
-	cmd += ledboard.ControlFlash + ledboard.FlashOn
-	cmd += ledboard.ControlFontColor + ledboard.FontColorRed
-	cmd += "!  ALARM  !"
-	cmd += ledboard.ControlFlash + ledboard.FlashOff
-	cmd += ledboard.PauseSecond2 + "04"
-
-	cmd += ledboard.ControlFrame
-
-	cmd += ledboard.FontNormal7x6
-	cmd += ledboard.ControlFontColor + ledboard.FontColorGreen
-	cmd += ledboard.ControlPatternIn + ledboard.PatternMoveUp
-	cmd += ledboard.ControlPatternOut + ledboard.PatternMoveLeft
-	cmd += utils.SanitizeUmlauts(message)
-	cmd += ledboard.PauseSecond2 + "30"
-
-	return cmd
+// UsePictures restricts the templates loaded afterwards to showing the
+// picture files with the given labels, those that are uploaded to the sign.
+func (s *Screens) UsePictures(labels ...string) {
+	s.pictures = append([]string{}, labels...)
 }
 
-// Donation generates the command string for the donation screen.
-func (s *Screens) Donation() string {
-	var cmd string
-
-	cmd += ledboard.FontNormal16x9
-	cmd += ledboard.ControlPatternIn + ledboard.PatternScrollUp
-	cmd += ledboard.ControlPatternOut + ledboard.PatternScrollUp
-
-	cmd += ledboard.ControlFlash + ledboard.FlashOn
-
-	cmd += ledboard.ControlFontColor + ledboard.FontColorYGRCharacter
-	cmd += "\\o/ Spende! \\o/"
-
-	cmd += ledboard.ControlFlash + ledboard.FlashOff
-
-	cmd += ledboard.PauseSecond2 + "04"
-
-	return cmd
+// text maps text to the character codes of the font. A custom font shows the
+// characters it has glyphs for, the others are transliterated as for the
+// builtin fonts.
+func (s *Screens) text(font ledboard.Font, text string) string {
+	supported := func(r rune) bool { return s.fonts.Has(font, r) }
+	return s.fonts.Text(font, charset.TransliterateFor(text, supported))
 }
 
-// DoorBell generates the command string for the doorbell screen.
-func (s *Screens) DoorBell() string {
-	var cmd string
-
-	cmd += ledboard.ControlPatternIn + ledboard.PatternScrollUp
-	cmd += ledboard.ControlPatternOut + ledboard.PatternScrollUp
-
-	cmd += ledboard.FontNormal16x9
-	cmd += ledboard.ControlFlash + ledboard.FlashOn
-	cmd += ledboard.ControlFontColor + ledboard.FontColorRed
-	cmd += "! DOORBELL !"
-	cmd += ledboard.ControlFlash + ledboard.FlashOff
-	cmd += ledboard.PauseSecond2 + "10"
-
-	return cmd
+// Alarm generates the Alarm screen.
+func (s *Screens) Alarm(message string) *ledboard.Screen {
+	return s.render("alarm", newData(message, 0))
 }
 
-// Idle generates the command string for the idle screen.
-func (s *Screens) Idle(memberCount int) string {
-	var cmd string
-
-	cmd += ledboard.FontNormal7x6
-	cmd += ledboard.ControlPatternIn + ledboard.PatternScrollUp
-	cmd += ledboard.ControlPatternOut + ledboard.PatternScrollUp
-
-	cmd += ledboard.ControlFontColor + ledboard.FontColorGreen
-
-	cmd += ledboard.ControlSpecial + ledboard.SpecialYYYY + "-"
-	cmd += ledboard.ControlSpecial + ledboard.SpecialMM + "-"
-	cmd += ledboard.ControlSpecial + ledboard.SpecialDD + " "
-
-	cmd += ledboard.ControlFontColor + ledboard.FontColorRed
-
-	cmd += ledboard.ControlSpecial + ledboard.SpecialHH + ":"
-	cmd += ledboard.ControlSpecial + ledboard.SpecialMIN + ":"
-	cmd += ledboard.ControlSpecial + ledboard.SpecialSEC
-
-	cmd += ledboard.ControlLineFeed
-
-	cmd += ledboard.ControlFontColor + ledboard.FontColorYellow
-	cmd += fmt.Sprintf("members present: %d", memberCount)
-	cmd += ledboard.PauseSecond4 + "9999"
-
-	return cmd
+// Donation generates the donation screen.
+func (s *Screens) Donation() *ledboard.Screen {
+	return s.render("donation", newData("", 0))
 }
 
-// LaserFinished generates the command string for the laser finished screen.
-func (s *Screens) LaserFinished(duration int) string {
-	var cmd string
-
-	cmd += ledboard.ControlPatternIn + ledboard.PatternScrollUp
-	cmd += ledboard.ControlPatternOut + ledboard.PatternScrollUp
-
-	if duration > 10*60 {
-		cmd += ledboard.FontNormal14x8
-		cmd += ledboard.ControlFontColor + ledboard.FontColorGreen
-
-		// Blinkin' for the poor (because FLASH seems buggy)
-		for i := 0; i < 3; i++ {
-			cmd += "Congratulations!"
-			cmd += ledboard.PauseMillisecond4 + "0400"
-			cmd += ledboard.ControlFrame
-			cmd += " "
-			cmd += ledboard.PauseMillisecond4 + "0100"
-			cmd += ledboard.ControlFrame
-		}
-	}
-
-	cmd += ledboard.ControlPatternIn + ledboard.PatternPeelOffR
-
-	cmd += ledboard.FontNormal7x6
-	cmd += ledboard.ControlFontColor + ledboard.FontColorGreen
-	cmd += ledboard.ControlFlash + ledboard.FlashOff
-
-	cmd += "Laser-Job finished:"
-
-	cmd += ledboard.ControlLineFeed
-	cmd += ledboard.ControlFontColor + ledboard.FontColorRed
-
-	hours := duration / 3600
-	minutes := (duration % 3600) / 60
-	seconds := duration % 60
-
-	if hours > 0 {
-		cmd += fmt.Sprintf("%dh ", hours)
-	}
-
-	if minutes > 0 {
-		cmd += fmt.Sprintf("%dm ", minutes)
-	}
-
-	if seconds > 0 {
-		cmd += fmt.Sprintf("%ds", seconds)
-	}
-
-	cmd += ledboard.PauseSecond4 + "0120"
-
-	return cmd
+// DoorBell generates the doorbell screen.
+func (s *Screens) DoorBell() *ledboard.Screen {
+	return s.render("doorbell", newData("", 0))
 }
 
-// LaserOperation generates the command string for the laser operation screen.
-func (s *Screens) LaserOperation() string {
-	var cmd string
-
-	cmd += ledboard.ControlPatternIn + ledboard.PatternRadarScan
-
-	cmd += ledboard.FontNormal15x9
-	cmd += ledboard.ControlFontColor + ledboard.FontColorRed
-
-	cmd += ledboard.ControlSpecial + ledboard.SpecialHH + "h "
-	cmd += ledboard.ControlSpecial + ledboard.SpecialMIN + "m "
-	cmd += ledboard.ControlSpecial + ledboard.SpecialSEC + "s "
-
-	cmd += ledboard.PauseSecond4 + "9999"
-
-	return cmd
+// Idle generates the idle screen. The member count and status are string
+// files, so they change without the screen starting over.
+func (s *Screens) Idle() *ledboard.Screen {
+	return s.render("idle", newData("", 0))
 }
 
-// NewMemberRegistration generates the command string for the new member registration screen.
-func (s *Screens) NewMemberRegistration(nickname string) string {
-	var cmd string
-
-	cmd += ledboard.ControlPatternIn + ledboard.PatternRadarScan
-
-	colors := []string{ledboard.FontColorGreen, ledboard.FontColorRed, ledboard.FontColorYGRHorizontal}
-	for _, color := range colors {
-		cmd += ledboard.FontNormal7x6
-		cmd += ledboard.ControlFontColor + color
-		cmd += "Herzlich Willkommen im backspace!"
-		cmd += ledboard.PauseSecond2 + "01"
-		cmd += ledboard.ControlFrame
+// Status returns the value of StatusString. While sending to a sign fails,
+// the other signs show degraded status with an exclamation mark.
+func Status(degraded bool) string {
+	if degraded {
+		return " !"
 	}
-
-	cmd += ledboard.FontNormal16x9
-	cmd += ledboard.ControlFontColor + ledboard.FontColorYellow
-	cmd += ledboard.ControlPatternIn + ledboard.PatternMoveUp
-	cmd += ledboard.ControlPatternOut + ledboard.PatternMoveLeft
-	cmd += nickname
-	cmd += ledboard.PauseSecond2 + "30"
-
-	return cmd
+	return ""
 }
 
-// NowPlaying generates the command string for the now playing screen.
-func (s *Screens) NowPlaying(message string) string {
-	var cmd string
-
-	cmd += ledboard.ControlPatternIn + ledboard.PatternRadarScan
-
-	cmd += ledboard.FontNormal7x6
-
-	cmd += ledboard.ControlFontColor + ledboard.FontColorYellow
-	cmd += "NOW PLAYING"
-
-	cmd += ledboard.PauseSecond2 + "05"
-	cmd += ledboard.ControlFrame
-
-	cmd += utils.SanitizeUmlauts(message)
-	cmd += ledboard.PauseSecond2 + "45"
-
-	return cmd
+// LaserFinished generates the laser finished screen for a job of duration
+// seconds.
+func (s *Screens) LaserFinished(duration int) *ledboard.Screen {
+	return s.render("laser-finished", newData("", duration))
 }
 
-// PizzaTimer generates the command string for the pizza timer screen.
-func (s *Screens) PizzaTimer() string {
-	var cmd string
-
-	cmd += ledboard.FontNormal16x9
-	cmd += ledboard.ControlPatternIn + ledboard.PatternScrollUp
-	cmd += ledboard.ControlPatternOut + ledboard.PatternScrollUp
-
-	cmd += ledboard.ControlFlash + ledboard.FlashOn
-	cmd += ledboard.ControlFontColor + ledboard.FontColorYGRCharacter
-	cmd += "PIZZA IS READY!"
-
-	cmd += ledboard.ControlFlash + ledboard.FlashOff
-
-	cmd += ledboard.PauseSecond2 + "10"
-
-	return cmd
+// LaserOperation generates the laser operation screen.
+func (s *Screens) LaserOperation() *ledboard.Screen {
+	return s.render("laser-operation", newData("", 0))
 }
 
-// PublicServiceAnnouncement generates the command string for the public service announcement screen.
-func (s *Screens) PublicServiceAnnouncement(message string) string {
-	var cmd string
-
-	cmd += ledboard.ControlPatternIn + ledboard.PatternRadarScan
-	cmd += ledboard.ControlFlash + ledboard.FlashOn
-
-	cmd += ledboard.FontNormal7x6
-
-	cmd += ledboard.ControlFontColor + ledboard.FontColorYellow
-	cmd += "PUBLIC "
-
-	cmd += ledboard.ControlFontColor + ledboard.FontColorRed
-	cmd += "SERVICE "
-
-	cmd += ledboard.ControlFontColor + ledboard.FontColorGreen
-	cmd += "ANNOUNCEMENT"
-
-	cmd += ledboard.ControlFlash + ledboard.FlashOff
+// NewMemberRegistration generates the new member registration screen.
+func (s *Screens) NewMemberRegistration(nickname string) *ledboard.Screen {
+	return s.render("new-member", newData(nickname, 0))
+}
 
-	cmd += ledboard.PauseSecond2 + "05"
-	cmd += ledboard.ControlFrame
+// NowPlaying generates the now playing screen.
+func (s *Screens) NowPlaying(message string) *ledboard.Screen {
+	return s.render("now-playing", newData(message, 0))
+}
 
-	cmd += utils.SanitizeUmlauts(message)
-	cmd += ledboard.PauseSecond2 + "45"
+// PizzaTimer generates the pizza timer screen.
+func (s *Screens) PizzaTimer() *ledboard.Screen {
+	return s.render("pizza", newData("", 0))
+}
 
-	return cmd
+// PublicServiceAnnouncement generates the public service announcement screen.
+func (s *Screens) PublicServiceAnnouncement(message string) *ledboard.Screen {
+	return s.render("psa", newData(message, 0))
 }
@@ -0,0 +1,240 @@
+package screens
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+)
+
+// defaultTemplates are the screens built into the daemon, used unless a
+// template file is loaded.
+//
+//go:embed screens.json
+var defaultTemplates []byte
+
+// screenNames are the screens every template file defines, it may add
+// screens of its own.
+var screenNames = []string{
+	"alarm", "donation", "doorbell", "idle", "laser-finished", "laser-operation",
+	"new-member", "now-playing", "pizza", "psa",
+}
+
+// Data is what the screen templates show, like {{.Message}}. Strings are
+// escaped for the markup.
+type Data struct {
+	// Message is the text of an MQTT message, like a nickname or song.
+	Message string
+	// Duration is a time in seconds, split into Hours, Minutes and Seconds.
+	Duration int
+	Hours    int
+	Minutes  int
+	Seconds  int
+}
+
+// screenName is the form of screen names.
+var screenName = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// sampleData covers the branches of the default templates, every template
+// is tried with them when it is loaded.
+var sampleData = []Data{
+	newData("Sample message {with braces}", 0),
+	newData(strings.Repeat("A much longer sample message ", 10), 3*3600+25*60+7),
+}
+
+func newData(message string, duration int) Data {
+	return Data{
+		Message:  escape(message),
+		Duration: duration,
+		Hours:    duration / 3600,
+		Minutes:  (duration % 3600) / 60,
+		Seconds:  duration % 60,
+	}
+}
+
+// Templates holds the template of every screen, executed into markup.
+type Templates struct {
+	screens map[string]*template.Template
+}
+
+// parseTemplates parses a template file: a JSON object mapping each screen
+// name to its template, either a string or an array of strings that are
+// joined. Names are lower case words joined by dashes. Every template is
+// tried with sample data, so a bad edit shows up when the file is loaded,
+// not when the screen is shown.
+func (s *Screens) parseTemplates(data []byte) (*Templates, error) {
+	var file map[string]json.RawMessage
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	t := &Templates{screens: make(map[string]*template.Template)}
+	for name, raw := range file {
+		if !screenName.MatchString(name) {
+			return nil, fmt.Errorf("invalid screen name %q", name)
+		}
+		var source string
+		if err := json.Unmarshal(raw, &source); err != nil {
+			var lines []string
+			if err := json.Unmarshal(raw, &lines); err != nil {
+				return nil, fmt.Errorf("screen %q: expected a string or an array of strings", name)
+			}
+			source = strings.Join(lines, "")
+		}
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(source)
+		if err != nil {
+			return nil, err
+		}
+		t.screens[name] = tmpl
+	}
+
+	for _, name := range screenNames {
+		if _, ok := t.screens[name]; !ok {
+			return nil, fmt.Errorf("screen %q is missing", name)
+		}
+	}
+	for name := range t.screens {
+		for _, data := range sampleData {
+			if _, err := s.execute(t, name, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return t, nil
+}
+
+// execute builds a screen from its template.
+func (s *Screens) execute(t *Templates, name string, data Data) (*ledboard.Screen, error) {
+	var markup bytes.Buffer
+	if err := t.screens[name].Execute(&markup, data); err != nil {
+		return nil, err
+	}
+	screen, err := s.parseMarkup(markup.String())
+	if err != nil {
+		return nil, fmt.Errorf("screen %q: %w", name, err)
+	}
+	return screen, nil
+}
+
+// render builds a screen from the current templates. Should that fail for
+// the data, the screen is built from the default templates.
+func (s *Screens) render(name string, data Data) *ledboard.Screen {
+	screen, err := s.execute(s.templates.Load(), name, data)
+	if err == nil {
+		return screen
+	}
+	slog.Error("failed to render screen, using the default", "screen", name, "error", err)
+	screen, err = s.execute(s.defaults, name, data)
+	if err != nil {
+		// The default templates are tried at startup, a failure is a bug.
+		panic(err)
+	}
+	return screen
+}
+
+// Render builds the named screen, one of the template file's own screens
+// too, showing a message and a duration in seconds.
+func (s *Screens) Render(name, message string, duration int) (*ledboard.Screen, error) {
+	if slices.Contains(screenNames, name) {
+		return s.render(name, newData(message, duration)), nil
+	}
+	t := s.templates.Load()
+	if _, ok := t.screens[name]; !ok {
+		return nil, fmt.Errorf("unknown screen %q", name)
+	}
+	return s.execute(t, name, newData(message, duration))
+}
+
+// Has reports whether the current templates define the named screen.
+func (s *Screens) Has(name string) bool {
+	_, ok := s.templates.Load().screens[name]
+	return ok
+}
+
+// Load replaces the templates with those of the template file at path. The
+// current templates are kept if the file is invalid.
+func (s *Screens) Load(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	t, err := s.parseTemplates(data)
+	if err != nil {
+		return fmt.Errorf("invalid screen templates %s: %w", path, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.path, s.modTime = path, info.ModTime()
+	s.templates.Store(t)
+	return nil
+}
+
+// Watch loads the template file again when it changed, checking every
+// interval, or when reload receives, until the context is done. changed is
+// called after the templates were replaced, so screens stored on the sign
+// can be written again.
+func (s *Screens) Watch(ctx context.Context, interval time.Duration, reload <-chan os.Signal, changed func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		forced := false
+		select {
+		case <-ctx.Done():
+			return
+		case <-reload:
+			forced = true
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		path, modTime := s.path, s.modTime
+		s.mu.Unlock()
+		if path == "" {
+			continue
+		}
+		if !forced {
+			info, err := os.Stat(path)
+			if err != nil {
+				slog.Debug("unable to check screen templates", "file", path, "error", err)
+				continue
+			}
+			if info.ModTime().Equal(modTime) {
+				continue
+			}
+		}
+
+		if err := s.Load(path); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				slog.Warn("screen templates are gone, keeping the current ones", "file", path)
+			} else {
+				slog.Error("keeping the current screen templates", "error", err)
+			}
+			// Don't complain about the same edit again.
+			s.mu.Lock()
+			if info, err := os.Stat(path); err == nil {
+				s.modTime = info.ModTime()
+			}
+			s.mu.Unlock()
+			continue
+		}
+		slog.Info("reloaded screen templates", "file", path)
+		changed()
+	}
+}
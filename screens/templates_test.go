@@ -0,0 +1,253 @@
+package screens
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+)
+
+// templateFile returns the default templates with the screens changed, a nil
+// template removes the screen.
+func templateFile(t *testing.T, changes map[string]any) []byte {
+	t.Helper()
+	var file map[string]any
+	if err := json.Unmarshal(defaultTemplates, &file); err != nil {
+		t.Fatal(err)
+	}
+	for name, template := range changes {
+		if template == nil {
+			delete(file, name)
+		} else {
+			file[name] = template
+		}
+	}
+	data, err := json.Marshal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseTemplates(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes map[string]any
+		err     string
+	}{
+		{name: "defaults"},
+		{name: "own screen", changes: map[string]any{"open-day": "{font normal-7x6}Open day{pause 10}"}},
+		{name: "array", changes: map[string]any{"psa": []string{"{font normal-7x6}", "{{.Message}}{pause 5}"}}},
+		{name: "upper case name", changes: map[string]any{"OpenDay": "x"}, err: `invalid screen name "OpenDay"`},
+		{name: "trailing dash", changes: map[string]any{"open-": "x"}, err: `invalid screen name "open-"`},
+		{name: "number", changes: map[string]any{"psa": 42}, err: "expected a string or an array of strings"},
+		{name: "template syntax", changes: map[string]any{"psa": "{{.Message"}, err: "psa"},
+		{name: "missing screen", changes: map[string]any{"doorbell": nil}, err: `screen "doorbell" is missing`},
+		{name: "unknown field", changes: map[string]any{"psa": "{{.Nick}}{pause 5}"}, err: "Nick"},
+		{name: "unknown command", changes: map[string]any{"psa": "{blink fast}{{.Message}}"}, err: "{blink fast}: unknown command"},
+		{name: "unknown color", changes: map[string]any{"psa": "{color pink}{{.Message}}"}, err: "pink"},
+		{name: "empty screen", changes: map[string]any{"psa": ""}, err: `screen "psa": screen is empty`},
+		{
+			name:    "failing for the long sample",
+			changes: map[string]any{"psa": "{{if gt .Hours 1}}{pause 10000}{{end}}{{.Message}}"},
+			err:     "expected 0 to 9999 seconds",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := NewScreens(DefaultBoard, nil)
+			_, err := s.parseTemplates(templateFile(t, test.changes))
+			switch {
+			case test.err == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case test.err != "" && err == nil:
+				t.Errorf("parsed, want error %q", test.err)
+			case test.err != "" && !strings.Contains(err.Error(), test.err):
+				t.Errorf("error %q, want %q", err, test.err)
+			}
+		})
+	}
+
+	if _, err := NewScreens(DefaultBoard, nil).parseTemplates([]byte(`["psa"]`)); err == nil {
+		t.Error("parsed a JSON array")
+	}
+}
+
+func TestRenderFallback(t *testing.T) {
+	quiet(t)
+	// The templates fail for a message the sample data doesn't have.
+	failing := "{{if eq .Message \"boom\"}}{color pink}{{end}}{font normal-7x6}{{.Message}}{pause 5}"
+	path := filepath.Join(t.TempDir(), "screens.json")
+	if err := os.WriteFile(path, templateFile(t, map[string]any{"psa": failing, "open-day": failing}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := NewScreens(DefaultBoard, nil)
+	if err := s.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	defaults := NewScreens(DefaultBoard, nil)
+
+	tests := []struct {
+		name, screen, message string
+		want                  *ledboard.Screen
+		err                   bool
+	}{
+		{name: "loaded", screen: "psa", message: "Lunch", want: frame(func(f *ledboard.Frame) {
+			f.Font(ledboard.FontNormal7x6).Text("Lunch").PauseSeconds(5)
+		})},
+		{name: "default", screen: "psa", message: "boom", want: defaults.PublicServiceAnnouncement("boom")},
+		{name: "own screen", screen: "open-day", message: "Welcome", want: frame(func(f *ledboard.Frame) {
+			f.Font(ledboard.FontNormal7x6).Text("Welcome").PauseSeconds(5)
+		})},
+		{name: "own screen without default", screen: "open-day", message: "boom", err: true},
+		{name: "unknown screen", screen: "closed-day", err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := s.Render(test.screen, test.message, 0)
+			if test.err {
+				if err == nil {
+					t.Errorf("rendered %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("screen\n%s, want\n%s", got, test.want)
+			}
+		})
+	}
+
+	if !s.Has("open-day") || s.Has("closed-day") || defaults.Has("open-day") {
+		t.Error("Has doesn't report the screens of the templates loaded")
+	}
+}
+
+func TestLoadKeepsTemplates(t *testing.T) {
+	s := NewScreens(DefaultBoard, nil)
+	path := filepath.Join(t.TempDir(), "screens.json")
+	if err := os.WriteFile(path, templateFile(t, map[string]any{"open-day": "Open{pause 1}"}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, templateFile(t, map[string]any{"open-day": "{blink}"}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Load(path); err == nil {
+		t.Fatal("loaded invalid templates")
+	}
+	if !s.Has("open-day") {
+		t.Error("the templates loaded before were replaced")
+	}
+	if err := s.Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("loaded a missing file")
+	}
+}
+
+func TestParseMarkup(t *testing.T) {
+	tests := []struct {
+		source string
+		want   *ledboard.Screen
+		err    string
+	}{
+		{
+			source: "{font normal-7x6}{color red}Hello {{world}{pause 400ms}",
+			want: frame(func(f *ledboard.Frame) {
+				f.Font(ledboard.FontNormal7x6).Color(ledboard.FontColorRed).Text("Hello {world}").PauseMilliseconds(400)
+			}),
+		},
+		{
+			source: "a{nl}b{half}c{pause 2}{frame}d{pause 0}",
+			want: frames(func(f *ledboard.Frame) {
+				f.Text("a").LineFeed().Text("b").HalfSpace().Text("c").PauseSeconds(2)
+			}, func(f *ledboard.Frame) {
+				f.Text("d").PauseSeconds(0)
+			}),
+		},
+		{
+			source: "{flash on}{special hh}:{special min}{flash off}{variable M}",
+			want: frame(func(f *ledboard.Frame) {
+				f.Flash(true).Special(ledboard.SpecialHH).Text(":").Special(ledboard.SpecialMIN).Flash(false).Variable("M")
+			}),
+		},
+		{
+			source: "{fit fonts=normal-7x6 color=green seconds=4}Hi {{there}{/fit}",
+			want: frame(func(f *ledboard.Frame) {
+				f.Font(ledboard.FontNormal7x6).Color(ledboard.FontColorGreen).Text("Hi {there}").PauseSeconds(4)
+			}),
+		},
+		{source: "", err: "screen is empty"},
+		{source: "{color red", err: "unterminated command"},
+		{source: "{}", err: "empty command"},
+		{source: "{nl now}", err: "takes no argument"},
+		{source: "{color}", err: "takes a single argument"},
+		{source: "{flash maybe}", err: "expected on or off"},
+		{source: "a{pause 10s}", err: "expected 0 to 9999 seconds"},
+		{source: "a{pause 10000ms}", err: "expected 0ms to 9999ms"},
+		{source: "{fit}a", err: "missing {/fit}"},
+		{source: "{fit}a{nl}b{/fit}", err: "commands are not allowed in {fit}"},
+		{source: "{fit seconds}a{/fit}", err: "expected key=value"},
+		{source: "{fit size=2}a{/fit}", err: "unknown option"},
+		{source: "{fit seconds=10000}a{/fit}", err: "out of range"},
+		{source: "a{font normal-7x6}", err: "font is not followed by any text"},
+	}
+
+	s := NewScreens(DefaultBoard, nil)
+	for _, test := range tests {
+		got, err := s.parseMarkup(test.source)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("parseMarkup(%q) error %v, want %q", test.source, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseMarkup(%q): %v", test.source, err)
+		} else if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseMarkup(%q)\n%s, want\n%s", test.source, got, test.want)
+		}
+	}
+}
+
+func TestParseMarkupPictures(t *testing.T) {
+	s := NewScreens(DefaultBoard, nil)
+	s.UsePictures("P")
+	if _, err := s.parseMarkup("{picture P}"); err != nil {
+		t.Error(err)
+	}
+	if _, err := s.parseMarkup("{picture Q}"); err == nil {
+		t.Error("showed a picture that isn't uploaded")
+	}
+}
+
+// frames builds a screen of a frame per function.
+func frames(build ...func(f *ledboard.Frame)) *ledboard.Screen {
+	screen := ledboard.NewScreen()
+	for _, b := range build {
+		b(screen.AddFrame())
+	}
+	return screen
+}
+
+// frame builds a screen of a single frame.
+func frame(build func(f *ledboard.Frame)) *ledboard.Screen {
+	return frames(build)
+}
+
+// quiet drops the log output of the test.
+func quiet(t *testing.T) {
+	logger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	t.Cleanup(func() { slog.SetDefault(logger) })
+}
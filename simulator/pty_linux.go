@@ -0,0 +1,83 @@
+//go:build linux
+
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// ListenPTY creates a pseudo terminal pair and serves packets written to its
+// serial side, whose path is logged, e.g. for serial:///dev/pts/3.
+func (s *Sign) ListenPTY(ctx context.Context) error {
+	master, slave, err := openPTY()
+	if err != nil {
+		return err
+	}
+	defer slave.Close()
+	go func() {
+		<-ctx.Done()
+		_ = master.Close()
+	}()
+
+	slog.Info("serving serial line", "path", slave.Name(), "url", "serial://"+slave.Name())
+
+	err = s.Serve(master, slave.Name())
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// openPTY creates a pseudo terminal pair. Holding the serial side open keeps
+// the pair alive while clients come and go, it is in raw mode so answers
+// aren't echoed or held back.
+func openPTY() (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open pseudo terminal: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = master.Close()
+		}
+	}()
+
+	var unlock int32
+	if err := ioctl(master.Fd(), syscall.TIOCSPTLCK, unsafe.Pointer(&unlock)); err != nil {
+		return nil, nil, fmt.Errorf("failed to unlock pseudo terminal: %w", err)
+	}
+	var n uint32
+	if err := ioctl(master.Fd(), syscall.TIOCGPTN, unsafe.Pointer(&n)); err != nil {
+		return nil, nil, fmt.Errorf("failed to get pseudo terminal number: %w", err)
+	}
+	path := fmt.Sprintf("/dev/pts/%d", n)
+
+	slave, err = os.OpenFile(path, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open pseudo terminal %s: %w", path, err)
+	}
+	var termios syscall.Termios
+	if err = ioctl(slave.Fd(), syscall.TCGETS, unsafe.Pointer(&termios)); err == nil {
+		termios.Iflag &^= syscall.ICRNL | syscall.IXON | syscall.INLCR | syscall.IGNCR
+		termios.Oflag &^= syscall.OPOST
+		termios.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+		err = ioctl(slave.Fd(), syscall.TCSETS, unsafe.Pointer(&termios))
+	}
+	if err != nil {
+		_ = slave.Close()
+		return nil, nil, fmt.Errorf("failed to configure pseudo terminal %s: %w", path, err)
+	}
+	return master, slave, nil
+}
+
+func ioctl(fd uintptr, request uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
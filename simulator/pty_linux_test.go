@@ -0,0 +1,75 @@
+package simulator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+)
+
+func TestServePTY(t *testing.T) {
+	// The BCD bytes of the date equal end of transmission, the packet still
+	// has to arrive whole.
+	date := time.Date(2026, 4, 4, 4, 4, 4, 0, time.UTC)
+	screen := ledboard.NewScreen()
+	screen.AddFrame().Font(ledboard.FontNormal7x6).Color(ledboard.FontColorGreen).Text("over the serial line")
+	screen.AddFrame().Special(ledboard.SpecialHHMin24).PauseSeconds(4)
+
+	for _, options := range []string{"", "&checksum=true"} {
+		t.Run("options="+options, func(t *testing.T) {
+			master, slave, err := openPTY()
+			if err != nil {
+				t.Skip(err)
+			}
+			defer slave.Close()
+			defer master.Close()
+			s := NewSign(160, 16)
+			go func() { _ = s.Serve(master, slave.Name()) }()
+
+			sign, err := ledboard.ParseSign("serial://" + slave.Name() + "?baud=9600" + options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client, err := ledboard.NewClient([]ledboard.Sign{sign}, nil, ledboard.QueueOptions{Gap: 10 * time.Millisecond, Size: 8})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			results, err := client.SetDateWait(ctx, date, ledboard.DefaultPolicy)
+			if err != nil || results[0].Status != ledboard.StatusAcked {
+				t.Fatalf("set date: %v %v", results, err)
+			}
+			s.mu.Lock()
+			clock := s.clock
+			s.mu.Unlock()
+			if !clock.Equal(date) {
+				t.Errorf("clock = %s, want %s", clock, date)
+			}
+
+			// The answer carries the date as well.
+			results, err = client.ReadDate(ctx, ledboard.DefaultPolicy)
+			if err != nil || results[0].Status != ledboard.StatusAcked {
+				t.Fatalf("read date: %v %v", results, err)
+			}
+			if read := results[0].Packet.Date; read.Before(date) || read.After(date.Add(5*time.Second)) {
+				t.Errorf("read date %s, want %s", read, date)
+			}
+
+			results, err = client.SendScreenWait(ctx, screen, ledboard.DefaultPolicy)
+			if err != nil || results[0].Status != ledboard.StatusAcked {
+				t.Fatalf("send screen: %v %v", results, err)
+			}
+			s.mu.Lock()
+			f := s.files[ledboard.DefaultFile]
+			s.mu.Unlock()
+			if f == nil || !reflect.DeepEqual(f.screen, screen) {
+				t.Errorf("stored file %+v, want the screen sent", f)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package simulator
+
+import (
+	"context"
+	"fmt"
+)
+
+// ListenPTY is only supported on Linux.
+func (s *Sign) ListenPTY(ctx context.Context) error {
+	return fmt.Errorf("pseudo terminals are only supported on Linux")
+}
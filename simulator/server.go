@@ -0,0 +1,143 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/emulator"
+)
+
+// ListenUDP receives datagrams on addr, like the sign does on port 9520.
+func (s *Sign) ListenUDP(ctx context.Context, addr string) error {
+	conn, err := net.ListenPacket("udp4", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on udp %s: %w", addr, err)
+	}
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+	slog.Info("listening for datagrams", "address", conn.LocalAddr())
+
+	buf := make([]byte, 65536)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to read datagram: %w", err)
+		}
+		reply, err := s.Handle(string(buf[:n]))
+		if err != nil {
+			slog.Error("rejecting datagram", "from", from, "error", err)
+		}
+		if reply != "" {
+			if _, err := conn.WriteTo([]byte(reply), from); err != nil {
+				slog.Error("failed to answer datagram", "to", from, "error", err)
+			}
+		}
+	}
+}
+
+// ListenEcho accepts TCP connections on addr and echoes what it receives,
+// so the daemon's ping probe sees the sign as online.
+func (s *Sign) ListenEcho(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp4", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on tcp %s: %w", addr, err)
+	}
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+	slog.Info("listening for echo probes", "address", listener.Addr())
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to accept echo connection: %w", err)
+		}
+		go func() {
+			defer conn.Close()
+			_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+			_, _ = io.Copy(conn, conn)
+		}()
+	}
+}
+
+// RunTerminal redraws the sign in the terminal until ctx is done.
+func (s *Sign) RunTerminal(ctx context.Context, w io.Writer, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Clear the screen once, then keep drawing over the same area.
+	fmt.Fprint(w, "\x1b[2J")
+	for {
+		var buf bytes.Buffer
+		buf.WriteString("\x1b[H")
+		if err := emulator.WriteANSI(&buf, s.Snapshot()); err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, "sign clock: %s brightness: %d%%\n", s.Clock().Format(time.DateTime), s.Brightness())
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+const page = `<!DOCTYPE html>
+<html>
+<head><title>ledboard simulator</title></head>
+<body style="background:#000;color:#888;font-family:monospace">
+<img id="board" src="board.png">
+<p id="clock"></p>
+<button onclick="fetch('reboot', {method: 'POST'})">reboot</button>
+<script>
+setInterval(function() {
+	document.getElementById("board").src = "board.png?" + Date.now();
+	fetch("clock").then(r => r.text()).then(t => document.getElementById("clock").textContent = t);
+}, %d);
+</script>
+</body>
+</html>
+`
+
+// Handler serves a web page showing the sign live.
+func (s *Sign) Handler(scale int, refresh time.Duration) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, page, refresh.Milliseconds())
+	})
+	mux.HandleFunc("/board.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := emulator.WritePNG(w, s.Snapshot(), scale); err != nil {
+			slog.Error("failed to write board image", "error", err)
+		}
+	})
+	mux.HandleFunc("POST /reboot", func(w http.ResponseWriter, r *http.Request) {
+		s.Reboot()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/clock", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "sign clock: %s brightness: %d%%\n", s.Clock().Format(time.DateTime), s.Brightness())
+	})
+	return mux
+}
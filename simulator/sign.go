@@ -0,0 +1,347 @@
+package simulator
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/b4ckspace/ledboard-v2/emulator"
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+)
+
+// file is a text file stored on the sign.
+type file struct {
+	screen  *ledboard.Screen
+	storage string
+}
+
+// Sign simulates the state of a physical LED board: its memory configuration,
+// the text files stored in RAM or flash, the run sequence and its clock.
+type Sign struct {
+	mu sync.Mutex
+
+	// Type and Address identify the sign, it also accepts packets sent to
+	// all types or to the broadcast address.
+	Type    string
+	Address string
+
+	board       *emulator.Board
+	allocations []ledboard.FileAllocation
+	files       map[string]*file
+	sequence    []string
+
+	// started is when the run sequence was last restarted.
+	started time.Time
+
+	// clock is the sign's time when clockSet was reached.
+	clock    time.Time
+	clockSet time.Time
+
+	// brightness is the dimming level in percent.
+	brightness int
+
+	config ledboard.SignConfig
+	// testPattern is set while the built-in test pattern is shown instead
+	// of the run sequence.
+	testPattern bool
+}
+
+// NewSign creates a simulated sign with the given pixel geometry.
+func NewSign(width, height int) *Sign {
+	now := time.Now()
+	return &Sign{
+		Type:       ledboard.PacketTypeAll,
+		Address:    "01",
+		board:      emulator.NewBoard(width, height),
+		files:      make(map[string]*file),
+		sequence:   []string{ledboard.DefaultFile},
+		brightness: ledboard.MaxBrightness,
+		config:     ledboard.SignConfig{Speaker: true, Clock24: true},
+		clock:      time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(), 0, time.UTC),
+		clockSet:   now,
+	}
+}
+
+// Handle applies a single datagram to the sign, as the real sign would, and
+// returns the sign's answer. Datagrams for other signs get no answer.
+func (s *Sign) Handle(datagram string) (string, error) {
+	packet, err := ledboard.Decode(datagram)
+	if err != nil {
+		return ledboard.PacketNak, fmt.Errorf("invalid datagram: %w", err)
+	}
+	slog.Debug("received packet\n" + packet.String())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if packet.Type != ledboard.PacketTypeAll && packet.Type != s.Type {
+		slog.Debug("ignoring packet for other sign type", "type", packet.Type)
+		return "", nil
+	}
+	if packet.Address != ledboard.PacketAddressAll && packet.Address != s.Address {
+		slog.Debug("ignoring packet for other address", "address", packet.Address)
+		return "", nil
+	}
+
+	// Anything but beeping and reading brings the sign back from its test
+	// pattern.
+	if packet.Command != ledboard.CommandReadText && packet.Command != ledboard.CommandReadFunction &&
+		packet.Function != ledboard.FunctionBeep {
+		s.testPattern = false
+	}
+
+	switch packet.Command {
+	case ledboard.CommandWriteText:
+		if err := s.writeFile(packet); err != nil {
+			return ledboard.PacketNak, err
+		}
+	case ledboard.CommandWritePicture:
+		if err := s.writePicture(packet); err != nil {
+			return ledboard.PacketNak, err
+		}
+	case ledboard.CommandWriteString:
+		if err := s.writeString(packet); err != nil {
+			return ledboard.PacketNak, err
+		}
+	case ledboard.CommandSpecialFunction:
+		switch packet.Function {
+		case ledboard.FunctionDate:
+			s.clock = packet.Date
+			s.clockSet = time.Now()
+			slog.Info("clock set", "time", packet.Date.Format(time.DateTime))
+		case ledboard.FunctionMemory:
+			s.allocate(packet.Memory)
+		case ledboard.FunctionFont:
+			s.board.Fonts[packet.FontSlot] = packet.Font
+			slog.Info("custom font uploaded", "slot", packet.FontSlot, "glyphs", len(packet.Font.Glyphs))
+		case ledboard.FunctionBrightness:
+			s.brightness = packet.Brightness
+			slog.Info("brightness set", "percent", packet.Brightness)
+		case ledboard.FunctionRunSequence:
+			if err := s.setSequence(packet.Sequence); err != nil {
+				return ledboard.PacketNak, err
+			}
+		case ledboard.FunctionSoftReset:
+			s.reboot()
+		case ledboard.FunctionBeep:
+			if s.config.Speaker {
+				slog.Info("beep", "frequency", packet.Beep.Frequency, "duration", packet.Beep.Duration, "repeats", packet.Beep.Repeats)
+			} else {
+				slog.Info("beep while the speaker is off")
+			}
+		case ledboard.FunctionConfig:
+			s.config = packet.Config
+			slog.Info("configuration set", "speaker", packet.Config.Speaker, "clock24", packet.Config.Clock24)
+		case ledboard.FunctionTestPattern:
+			s.testPattern = packet.TestPattern
+			s.started = time.Now()
+			slog.Info("test pattern", "on", packet.TestPattern)
+		}
+	case ledboard.CommandReadText:
+		f, ok := s.files[packet.Label]
+		if !ok {
+			return ledboard.PacketNak, fmt.Errorf("read of unknown file %q", packet.Label)
+		}
+		encoded, err := f.screen.Encode()
+		if err != nil {
+			return ledboard.PacketNak, err
+		}
+		return s.respond(ledboard.TextFile(packet.Label, f.storage, encoded)), nil
+	case ledboard.CommandReadString:
+		value, ok := s.board.Strings[packet.Label]
+		if !ok {
+			return ledboard.PacketNak, fmt.Errorf("read of unknown string %q", packet.Label)
+		}
+		return s.respond(ledboard.StringFile(packet.Label, value)), nil
+	case ledboard.CommandReadFunction:
+		switch packet.Function {
+		case ledboard.FunctionDate:
+			return s.respond(ledboard.DateFunction(s.clockLocked())), nil
+		case ledboard.FunctionMemory:
+			return s.respond(ledboard.MemoryFunction(s.allocations)), nil
+		case ledboard.FunctionConfig:
+			return s.respond(ledboard.ConfigFunction(s.config)), nil
+		}
+	}
+	return ledboard.PacketAck, nil
+}
+
+// writeFile stores a text file. Only the default file may be written without
+// allocating memory for it first.
+func (s *Sign) writeFile(packet *ledboard.Packet) error {
+	encoded, err := packet.Screen.Encode()
+	if err != nil {
+		return err
+	}
+	size, ok := s.allocated(packet.Label, ledboard.FileTypeText)
+	if !ok {
+		return fmt.Errorf("write to unallocated file %q", packet.Label)
+	}
+	if size > 0 && len(encoded) > size {
+		return fmt.Errorf("file %q has %d bytes, only %d are allocated", packet.Label, len(encoded), size)
+	}
+
+	s.files[packet.Label] = &file{screen: packet.Screen, storage: packet.Storage}
+	if slices.Contains(s.sequence, packet.Label) {
+		s.started = time.Now()
+	}
+	slog.Info("stored text file", "label", packet.Label, "storage", packet.Storage, "frames", len(packet.Screen.Frames))
+	return nil
+}
+
+// writePicture stores a picture file, which must fit into its allocation.
+func (s *Sign) writePicture(packet *ledboard.Packet) error {
+	allocation, ok := s.allocation(packet.Label)
+	if !ok || allocation.Type != ledboard.FileTypePicture {
+		return fmt.Errorf("write to unallocated picture %q", packet.Label)
+	}
+	if packet.Picture.Width > allocation.Width || packet.Picture.Height > allocation.Height {
+		return fmt.Errorf("picture %q is %dx%d, only %dx%d are allocated", packet.Label,
+			packet.Picture.Width, packet.Picture.Height, allocation.Width, allocation.Height)
+	}
+
+	s.board.Pictures[packet.Label] = packet.Picture
+	s.started = time.Now()
+	slog.Info("stored picture", "label", packet.Label, "width", packet.Picture.Width, "height", packet.Picture.Height)
+	return nil
+}
+
+// writeString sets a string file, which must fit into its allocation. Text
+// files showing it keep running.
+func (s *Sign) writeString(packet *ledboard.Packet) error {
+	size, ok := s.allocated(packet.Label, ledboard.FileTypeString)
+	if !ok {
+		return fmt.Errorf("write to unallocated string %q", packet.Label)
+	}
+	if len(packet.Value) > size {
+		return fmt.Errorf("string %q has %d bytes, only %d are allocated", packet.Label, len(packet.Value), size)
+	}
+
+	s.board.Strings[packet.Label] = packet.Value
+	slog.Info("stored string", "label", packet.Label, "value", packet.Value)
+	return nil
+}
+
+func (s *Sign) allocation(label string) (ledboard.FileAllocation, bool) {
+	for _, allocation := range s.allocations {
+		if allocation.Label == label {
+			return allocation, true
+		}
+	}
+	return ledboard.FileAllocation{}, false
+}
+
+// allocated returns the size allocated for a file of the given type, zero if
+// unlimited.
+func (s *Sign) allocated(label, fileType string) (int, bool) {
+	if allocation, ok := s.allocation(label); ok {
+		return allocation.Size, allocation.Type == fileType
+	}
+	return 0, label == ledboard.DefaultFile && fileType == ledboard.FileTypeText
+}
+
+// allocate replaces the memory configuration, which erases all files. An
+// empty configuration clears the memory, the run sequence included.
+func (s *Sign) allocate(allocations []ledboard.FileAllocation) {
+	s.allocations = allocations
+	clear(s.files)
+	clear(s.board.Pictures)
+	clear(s.board.Strings)
+	s.started = time.Now()
+	if len(allocations) == 0 {
+		s.sequence = []string{ledboard.DefaultFile}
+		slog.Info("memory cleared")
+		return
+	}
+	slog.Info("memory configured", "files", len(allocations))
+}
+
+func (s *Sign) setSequence(labels []string) error {
+	for _, label := range labels {
+		if _, ok := s.allocated(label, ledboard.FileTypeText); !ok {
+			return fmt.Errorf("run sequence contains unallocated file %q", label)
+		}
+	}
+	s.sequence = labels
+	s.started = time.Now()
+	slog.Info("run sequence set", "labels", labels)
+	return nil
+}
+
+// Reboot simulates a power cycle: text files stored in RAM, pictures and
+// strings are lost and the sign is back at full brightness. The memory configuration,
+// flash files, custom fonts and run sequence are kept.
+func (s *Sign) Reboot() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reboot()
+}
+
+func (s *Sign) reboot() {
+	for label, f := range s.files {
+		if f.storage != ledboard.StorageFlash {
+			delete(s.files, label)
+		}
+	}
+	clear(s.board.Pictures)
+	clear(s.board.Strings)
+	s.brightness = ledboard.MaxBrightness
+	s.testPattern = false
+	s.started = time.Now()
+	slog.Info("rebooted", "files", len(s.files))
+}
+
+func (s *Sign) respond(payload string) string {
+	return ledboard.EncodeResponse(s.Address, payload)
+}
+
+// Clock returns the sign's current time. Its location is meaningless, the
+// sign only knows the wall clock.
+func (s *Sign) Clock() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.clockLocked()
+}
+
+// Brightness returns the sign's dimming level in percent.
+func (s *Sign) Brightness() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.brightness
+}
+
+func (s *Sign) clockLocked() time.Time {
+	return s.clock.Add(time.Since(s.clockSet))
+}
+
+// Snapshot renders what the sign shows right now.
+func (s *Sign) Snapshot() *emulator.Matrix {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.testPattern {
+		return s.testPatternLocked()
+	}
+
+	var screens []*ledboard.Screen
+	for _, label := range s.sequence {
+		if f, ok := s.files[label]; ok {
+			screens = append(screens, f.screen)
+		}
+	}
+	if len(screens) == 0 {
+		return emulator.NewMatrix(s.board.Width, s.board.Height)
+	}
+	return s.board.Snapshot(ledboard.Join(screens...), time.Since(s.started), s.clockLocked())
+}
+
+// testPatternLocked lights every LED, switching through the colors every
+// second.
+func (s *Sign) testPatternLocked() *emulator.Matrix {
+	colors := []emulator.Color{emulator.Red, emulator.Green, emulator.Yellow}
+	matrix := emulator.NewMatrix(s.board.Width, s.board.Height)
+	matrix.Fill(colors[int(time.Since(s.started)/time.Second)%len(colors)])
+	return matrix
+}
@@ -0,0 +1,64 @@
+package simulator
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+
+	"github.com/b4ckspace/ledboard-v2/ledboard"
+)
+
+// ListenTCP accepts TCP connections on addr and reads packets from them, like
+// a TCP to serial converter in front of the sign.
+func (s *Sign) ListenTCP(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp4", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on tcp %s: %w", addr, err)
+	}
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+	slog.Info("listening for packets", "address", listener.Addr())
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+		go func() {
+			defer conn.Close()
+			go func() {
+				<-ctx.Done()
+				_ = conn.Close()
+			}()
+			_ = s.Serve(conn, conn.RemoteAddr().String())
+		}()
+	}
+}
+
+// Serve reads packets from a byte stream, like a serial line, and writes the
+// answers back until the stream ends.
+func (s *Sign) Serve(rw io.ReadWriter, from string) error {
+	scanner := bufio.NewScanner(rw)
+	scanner.Buffer(make([]byte, 4096), 1<<20)
+	scanner.Split(ledboard.ScanPackets)
+	for scanner.Scan() {
+		reply, err := s.Handle(scanner.Text())
+		if err != nil {
+			slog.Error("rejecting packet", "from", from, "error", err)
+		}
+		if reply != "" {
+			if _, err := io.WriteString(rw, reply); err != nil {
+				return fmt.Errorf("failed to answer packet from %s: %w", from, err)
+			}
+		}
+	}
+	return scanner.Err()
+}
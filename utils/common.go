@@ -1,24 +1,5 @@
 package utils
 
-import (
-	"strings"
-)
-
-var umlautsMapping = map[string]string{
-	"ä": "ae",
-	"ü": "ue",
-	"ö": "oe",
-	"ß": "sz",
-}
-
-// SanitizeUmlauts replaces German umlauts with their two-letter equivalents.
-func SanitizeUmlauts(message string) string {
-	for key, value := range umlautsMapping {
-		message = strings.ReplaceAll(message, key, value)
-	}
-	return message
-}
-
 // Byte2Hex converts a byte to a hexadecimal string representation.
 // This function's logic seems unusual for a typical byte to hex conversion.
 // It appears to be converting decimal digits of the byte into a single character
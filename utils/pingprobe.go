@@ -2,25 +2,32 @@ package utils
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
 	"net"
 	"time"
 )
 
 type PingProbe struct {
-	host     string
+	address  string
 	interval time.Duration
 }
 
-// NewPingProbeInBackground monitors a host using ping in a go routine, once the
-// packages succeeds three times, the success func is called.
-func NewPingProbe(host string, intervalSeconds int) (*PingProbe, error) {
-	return &PingProbe{host, time.Duration(intervalSeconds) * time.Second}, nil
+// NewPingProbeInBackground monitors a TCP address, like the echo service of a
+// sign, in a go routine. Once connecting succeeds three times, the success
+// func is called. Without an address the sign is taken as always online.
+func NewPingProbe(address string, intervalSeconds int) (*PingProbe, error) {
+	return &PingProbe{address, time.Duration(intervalSeconds) * time.Second}, nil
 
 }
 
 func (p *PingProbe) Run(ctx context.Context, success func()) error {
+	if p.address == "" {
+		slog.Info("nothing to probe, assuming online")
+		success()
+		<-ctx.Done()
+		return nil
+	}
+
 	history := make([]bool, 3)
 	online := false
 	for {
@@ -31,7 +38,7 @@ func (p *PingProbe) Run(ctx context.Context, success func()) error {
 			time.Sleep(p.interval)
 		}
 
-		c, err := net.DialTimeout("tcp", fmt.Sprintf("%s:7", p.host), time.Second)
+		c, err := net.DialTimeout("tcp", p.address, time.Second)
 		if err == nil {
 			_ = c.Close()
 		}
@@ -40,11 +47,11 @@ func (p *PingProbe) Run(ctx context.Context, success func()) error {
 
 		if !online && history[0] && history[1] && history[2] {
 			online = true
-			slog.Info("went online", "host", p.host)
+			slog.Info("went online", "address", p.address)
 			success()
 		} else if online && (!history[0] || !history[1] || !history[2]) {
 			online = false
-			slog.Info("went offline", "host", p.host)
+			slog.Info("went offline", "address", p.address)
 		}
 	}
 }